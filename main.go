@@ -1,20 +1,48 @@
 package main
 
 import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"flag"
 	"fmt"
+	"go/format"
+	"go/token"
 	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"text/template"
 	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -22,1462 +50,8943 @@ import (
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/reflow/wrap"
-	_ "modernc.org/sqlite"
+	"github.com/muesli/termenv"
+	"modernc.org/sqlite"
 )
 
 var (
 	dbPath               = filepath.Join(os.Getenv("HOME"), ".webhook-tui", "webhooks.db")
+	configPath           = filepath.Join(os.Getenv("HOME"), ".webhook-tui", "config.json")
 	db                   *sql.DB
-	pageSize             = 20
+	config               Config
+	defaultPageSize      = 20
 	defaultTunnelTimeout = 30 * time.Minute
-)
 
-// Styles
-var (
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("205")).
-			Background(lipgloss.Color("235")).
-			Padding(0, 1)
+	// runID tags every webhook captured by this process, e.g. with a CI build
+	runID = os.Getenv("WEBHOOK_TUI_RUN_ID")
 
-	infoStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241"))
+	// sessionStart is when this process started, for per-session rates
+	sessionStart = time.Now()
 
-	successStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("82"))
+	// readinessPath is answered by the webhook server itself and never stored.
+	// It is used to verify that the tunnel actually routes traffic to us.
+	readinessPath       = "/__webhook-tui/ready"
+	maxVerifyAttempts   = 5
+	verifyRetryDelay    = 3 * time.Second
+	maxPublicIPAttempts = 3
 
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196"))
+	// Consecutive failed health checks before the tunnel is shown unreachable
+	healthFailThreshold = 2
+	defaultHealthCheck  = 30 * time.Second
 
-	highlightStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("212"))
+	// Multipart bodies are summarized per part; only this much of the raw body
+	// is stored, and text field values are capped for display
+	maxStoredMultipartBody = 1 << 20
+	maxPartValue           = 1024
 
-	selectedStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("205")).
-			Padding(0, 1)
+	// Header sets above defaultMaxHeaderBytes are flagged; individual values
+	// are stored up to maxStoredHeaderValue
+	defaultMaxHeaderBytes = 32 << 10
+	maxStoredHeaderValue  = 8 << 10
 
-	webhookItemStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("240")).
-				Padding(0, 1).
-				MarginBottom(1)
+	// Compressed bodies that inflate beyond this are stored as received
+	maxDecompressedBody = 10 << 20
+)
 
-	webhookSelectedStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("205")).
-				Padding(0, 1).
-				MarginBottom(1)
+// Config holds user settings read from ~/.webhook-tui/config.json
+type Config struct {
+	// Setup screen defaults; starting a session saves what was entered
+	DefaultPort           string `json:"default_port,omitempty"`
+	DefaultSubdomain      string `json:"default_subdomain,omitempty"`
+	DefaultTimeoutMinutes int    `json:"default_timeout_minutes,omitempty"`
+	DefaultProvider       string `json:"default_provider,omitempty"`
 
-	headerStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("39")).
-			Bold(true)
+	// "table" or "list"; saved whenever t switches the view
+	ViewMode string `json:"view_mode,omitempty"`
+
+	// JSONColumns are JSON paths (e.g. "data.object.amount") whose values
+	// are shown as extra columns in the table view
+	JSONColumns []string `json:"json_columns,omitempty"`
+
+	// MaxContentWidth caps the wrap width of the detail view (0 = viewport width)
+	MaxContentWidth int `json:"max_content_width,omitempty"`
+	// CenterContent centers narrower detail content instead of left-aligning it
+	CenterContent bool `json:"center_content,omitempty"`
 
-	bodyStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("252"))
+	// DeliveryIDHeader and DeliveryIDField name where a provider puts its
+	// delivery id (e.g. "X-GitHub-Delivery" or Stripe's "id"). Retries reuse it.
+	DeliveryIDHeader string `json:"delivery_id_header,omitempty"`
+	DeliveryIDField  string `json:"delivery_id_field,omitempty"`
 
-	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
-			Italic(true)
+	// SequenceHeader or SequenceField name a provider's increasing sequence
+	// number; gaps and out-of-order deliveries per path are flagged
+	SequenceHeader string `json:"sequence_header,omitempty"`
+	SequenceField  string `json:"sequence_field,omitempty"`
 
-	// JSON syntax highlighting styles
-	jsonKeyStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("81")) // cyan
+	// ForwardTo mirrors every captured webhook (method, path, headers, body)
+	// to this base URL, e.g. a local dev server; F pauses it for the session
+	ForwardTo string `json:"forward_to,omitempty"`
 
-	jsonStringStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("114")) // green
+	// PageSize is how many webhooks a page of the list holds (default 20);
+	// +/- change it at runtime and save it here
+	PageSize int `json:"page_size,omitempty"`
 
-	jsonNumberStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("222")) // yellow
+	// SigningSecrets holds the webhook signing secret per provider (see
+	// signatureHeaders); signed requests from them are verified on arrival
+	SigningSecrets map[string]string `json:"signing_secrets,omitempty"`
 
-	jsonBoolStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("212")) // magenta
+	// PauseExpiryInDetail stops the tunnel timeout counting down while a
+	// webhook is open in the detail view
+	PauseExpiryInDetail bool `json:"pause_expiry_in_detail,omitempty"`
 
-	jsonNullStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("244")) // gray
+	// AutoOpenDetail opens each new webhook in the detail view when the list is
+	// idle; 'A' toggles it for the session
+	AutoOpenDetail bool `json:"auto_open_detail,omitempty"`
 
-	jsonBracketStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("252")) // light gray
+	// Notifications shows a desktop notification for new webhooks; bursts
+	// are summarized (see notifyWindow). Ctrl+n toggles it for the session.
+	Notifications bool `json:"notifications,omitempty"`
 
-	lineNumberStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("239")) // dim gray
+	// MillisecondTimestamps shows arrival times as 15:04:05.000 so bursts can be ordered
+	MillisecondTimestamps bool `json:"millisecond_timestamps,omitempty"`
 
-	searchHighlightStyle = lipgloss.NewStyle().
-				Background(lipgloss.Color("226")). // yellow background
-				Foreground(lipgloss.Color("0"))    // black text
-)
+	// IdleTimeoutMinutes quits the app after this long without a webhook (0 = never)
+	IdleTimeoutMinutes int `json:"idle_timeout_minutes,omitempty"`
 
-// WebhookPayload represents an incoming webhook
-type WebhookPayload struct {
-	ID        int               `json:"id"`
-	Timestamp time.Time         `json:"timestamp"`
-	Method    string            `json:"method"`
-	Path      string            `json:"path"`
-	Headers   map[string]string `json:"headers"`
-	Body      string            `json:"body"`
-	BodyJSON  interface{}       `json:"body_json,omitempty"`
-}
+	// HealthCheckSeconds is how often the tunnel is pinged once verified
+	// (default 30, negative disables)
+	HealthCheckSeconds int `json:"health_check_seconds,omitempty"`
 
-// State represents the current view/state of the application
-type State int
+	// StripPathPrefix and StripPathRegex shorten paths in the list/table only;
+	// the full path is kept in the detail view and in storage
+	StripPathPrefix string `json:"strip_path_prefix,omitempty"`
+	StripPathRegex  string `json:"strip_path_regex,omitempty"`
 
-const (
-	StateSetup State = iota
-	StateRunning
-	StateDetail
-)
+	// MaxRows caps stored webhooks; the oldest are evicted on insert (0 = unlimited)
+	MaxRows int `json:"max_rows,omitempty"`
 
-// ViewMode represents how webhooks are displayed
-type ViewMode int
+	// RetainDays deletes webhooks older than this many days on startup and
+	// when P is pressed, then vacuums the database (0 = keep everything)
+	RetainDays int `json:"retain_days,omitempty"`
 
-const (
-	ViewModeList ViewMode = iota
-	ViewModeTable
-)
+	// DisableHistory stops recording tunnel URLs and public IPs per session
+	DisableHistory bool `json:"disable_history,omitempty"`
 
-// Model is the main application model
-type Model struct {
-	state          State
-	portInput      textinput.Model
-	subdomainInput textinput.Model
-	timeoutInput   textinput.Model
-	focusedInput   int
-	spinner        spinner.Model
-	viewport       viewport.Model
-	viewportReady  bool
+	// MaxHeaderBytes flags requests whose total header size exceeds it (default 32 KB)
+	MaxHeaderBytes int `json:"max_header_bytes,omitempty"`
 
-	publicIP           string
-	fetchingIP         bool
-	tunnelURL          string
-	tunnelRunning      bool
-	tunnelExpired      bool // true when auto-shutdown occurred
-	tunnelError        string
-	serverRunning      bool
-	requestedPort      string
-	requestedSubdomain string
-	tunnelTimeout      time.Duration // how long before auto-shutdown
-	tunnelStartTime    time.Time     // when tunnel was started
+	// DropHeaders stores no request headers; HeaderAllowlist stores only the
+	// named ones. Either way the headers are still used while handling the
+	// request (delivery ids, sequence numbers, response rules).
+	DropHeaders     bool     `json:"drop_headers,omitempty"`
+	HeaderAllowlist []string `json:"header_allowlist,omitempty"`
 
-	webhooks       []WebhookPayload
-	webhooksMu     sync.Mutex
-	selectedIdx    int
-	webhookChan    chan WebhookPayload
-	viewMode       ViewMode
+	// Capture limits which requests are stored; the rest get a 200 but no DB write
+	Capture CaptureFilter `json:"capture"`
 
-	// Pagination
-	currentPage    int
-	totalPages     int
-	totalWebhooks  int
+	// ContentTypeOverrides force how bodies are parsed and rendered, keyed by
+	// path prefix ("*" for all paths), for providers that mislabel payloads.
+	// The stored headers keep what was sent.
+	ContentTypeOverrides map[string]string `json:"content_type_overrides,omitempty"`
 
-	width          int
-	height         int
+	// ActiveHours keeps the tunnel up only inside a daily window; outside it
+	// the tunnel is stopped, and it is started again when the window opens
+	ActiveHours *ActiveHours `json:"active_hours,omitempty"`
 
-	tunnelCmd      *exec.Cmd
+	// StrictPath captures only requests to exactly this path; everything else
+	// gets a 404 and is counted, to catch providers posting to the wrong URL
+	StrictPath string `json:"strict_path,omitempty"`
 
-	// Search in detail view
-	searchMode       bool
-	searchInput      textinput.Model
-	searchQuery      string
-	searchMatches    []int  // line numbers with matches
-	searchMatchIdx   int    // current match index
-	detailContent    string // raw content for searching
-	detailGutterWidth int   // gutter width for line numbers
-}
+	// ListenSocket makes the server listen on this Unix socket instead of the
+	// TCP port. No tunnel is started, since tunnel providers need a TCP port.
+	ListenSocket string `json:"listen_socket,omitempty"`
 
-// Messages
-type publicIPMsg string
-type publicIPErrMsg error
-type tunnelStartedMsg struct {
-	url string
-	cmd *exec.Cmd
-}
-type tunnelErrorMsg string
-type serverStartedMsg struct{}
-type webhookReceivedMsg WebhookPayload
-type webhooksLoadedMsg struct {
-	webhooks      []WebhookPayload
-	totalCount    int
-	currentPage   int
-}
-type dbErrorMsg string
-type tunnelExpiredMsg struct{}
+	// AutoReconnect starts a new tunnel when the old one expires instead of
+	// waiting for r; failed attempts are retried with backoff
+	AutoReconnect bool `json:"auto_reconnect,omitempty"`
 
-func initDB() error {
-	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
-		return err
-	}
+	// HARHost is the scheme and host for URLs in HAR exports, e.g.
+	// "https://hooks.example.com"; the current tunnel URL is used when unset
+	HARHost string `json:"har_host,omitempty"`
 
-	var err error
-	db, err = sql.Open("sqlite", dbPath)
-	if err != nil {
-		return err
-	}
+	// APIPort serves the stored webhooks as JSON on 127.0.0.1 (0 = off)
+	APIPort int `json:"api_port,omitempty"`
 
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS webhooks (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-			method TEXT,
-			path TEXT,
-			headers TEXT,
-			body TEXT,
-			body_json TEXT
-		)
-	`)
-	return err
-}
+	// DebugLog is a file that diagnostic messages (e.g. dropped live events) are appended to
+	DebugLog string `json:"debug_log,omitempty"`
 
-func saveWebhookToDB(payload WebhookPayload) error {
-	if db == nil {
-		return fmt.Errorf("database not initialized")
-	}
+	// PrettyBodyJSON stores body_json indented, for reading the DB with other tools
+	PrettyBodyJSON bool `json:"pretty_body_json,omitempty"`
 
-	headersJSON, _ := json.Marshal(payload.Headers)
-	bodyJSON := ""
-	if payload.BodyJSON != nil {
-		b, _ := json.Marshal(payload.BodyJSON)
-		bodyJSON = string(b)
-	}
+	// ResponseRules pick a response from the request body; the first matching
+	// rule wins over method_responses and default_response
+	ResponseRules []ResponseRule `json:"response_rules,omitempty"`
 
-	// Store timestamp in RFC3339 format for consistent parsing
-	_, err := db.Exec(`
-		INSERT INTO webhooks (timestamp, method, path, headers, body, body_json)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, payload.Timestamp.Format(time.RFC3339), payload.Method, payload.Path, string(headersJSON), payload.Body, bodyJSON)
+	// AlertRules ring the terminal bell (or flash the title bar) when a
+	// webhook matches; they are checked as webhooks arrive, whatever is shown
+	AlertRules []AlertRule `json:"alert_rules,omitempty"`
 
-	return err
+	// MethodResponses sets the response per HTTP method (e.g. "OPTIONS": {"status": 204});
+	// other methods get DefaultResponse, or a plain 200 "OK" when that is unset
+	MethodResponses map[string]ResponseConfig `json:"method_responses,omitempty"`
+	DefaultResponse *ResponseConfig           `json:"default_response,omitempty"`
+
+	// ColorRules color list/table rows; the first matching rule wins
+	ColorRules []ColorRule `json:"color_rules,omitempty"`
+
+	// SpinnerStyle picks the loading animation (see spinnerStyles; default "dot")
+	SpinnerStyle string `json:"spinner_style,omitempty"`
+
+	stripPathRe *regexp.Regexp // compiled StripPathRegex
 }
 
-func loadWebhooksFromDB(page int) tea.Cmd {
-	return func() tea.Msg {
-		if db == nil {
-			return dbErrorMsg("Database not initialized")
-		}
+// knownDeliveryIDHeaders are checked when no delivery id header is configured
+var knownDeliveryIDHeaders = []string{
+	"X-GitHub-Delivery",
+	"X-Shopify-Webhook-Id",
+	"X-Gitlab-Event-UUID",
+	"Svix-Id",
+	"Webhook-Id",
+}
 
-		// Get total count
-		var totalCount int
-		err := db.QueryRow("SELECT COUNT(*) FROM webhooks").Scan(&totalCount)
-		if err != nil {
-			return dbErrorMsg(fmt.Sprintf("Failed to count webhooks: %v", err))
-		}
+// signatureHeaders is the header each signing_secrets provider signs with
+var signatureHeaders = map[string]string{
+	"github":  "X-Hub-Signature-256",
+	"shopify": "X-Shopify-Hmac-Sha256",
+	"stripe":  "Stripe-Signature",
+}
 
-		offset := page * pageSize
-		rows, err := db.Query(`
-			SELECT id, timestamp, method, path, headers, body, body_json
-			FROM webhooks
-			ORDER BY id DESC
-			LIMIT ? OFFSET ?
-		`, pageSize, offset)
-		if err != nil {
-			return dbErrorMsg(fmt.Sprintf("Failed to load webhooks: %v", err))
-		}
-		defer rows.Close()
+// verifySignature checks the HMAC of the raw body for the first provider
+// with a configured secret whose signature header is present. Returns the
+// provider and nil when there is nothing to verify.
+func verifySignature(header http.Header, body []byte) (string, *bool) {
+	providers := make([]string, 0, len(config.SigningSecrets))
+	for provider := range config.SigningSecrets {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
 
-		var webhooks []WebhookPayload
-		for rows.Next() {
-			var w WebhookPayload
-			var headersJSON, bodyJSON string
-			var timestamp string
+	for _, provider := range providers {
+		sig := header.Get(signatureHeaders[provider])
+		if sig == "" {
+			continue
+		}
+		valid := signatureMatches(provider, config.SigningSecrets[provider], sig, body)
+		return provider, &valid
+	}
+	return "", nil
+}
 
-			err := rows.Scan(&w.ID, &timestamp, &w.Method, &w.Path, &headersJSON, &w.Body, &bodyJSON)
-			if err != nil {
-				continue
-			}
+// signatureMatches compares a provider's signature header with the expected
+// HMAC-SHA256 in constant time
+func signatureMatches(provider, secret, sig string, body []byte) bool {
+	sum := func(parts ...[]byte) []byte {
+		mac := hmac.New(sha256.New, []byte(secret))
+		for _, p := range parts {
+			mac.Write(p)
+		}
+		return mac.Sum(nil)
+	}
 
-			// Try multiple timestamp formats
-			for _, format := range []string{
-				time.RFC3339,
-				"2006-01-02T15:04:05Z07:00",
-				"2006-01-02 15:04:05",
-				"2006-01-02T15:04:05",
-			} {
-				if t, err := time.Parse(format, timestamp); err == nil {
-					w.Timestamp = t
-					break
+	switch provider {
+	case "github":
+		// sha256=<hex>
+		got, err := hex.DecodeString(strings.TrimPrefix(sig, "sha256="))
+		return err == nil && hmac.Equal(got, sum(body))
+	case "shopify":
+		got, err := base64.StdEncoding.DecodeString(sig)
+		return err == nil && hmac.Equal(got, sum(body))
+	case "stripe":
+		// t=<timestamp>,v1=<hex>[,v1=<hex>...] signing "<timestamp>.<body>"
+		var timestamp string
+		var candidates [][]byte
+		for _, item := range strings.Split(sig, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(item), "=")
+			switch key {
+			case "t":
+				timestamp = value
+			case "v1":
+				if got, err := hex.DecodeString(value); err == nil {
+					candidates = append(candidates, got)
 				}
 			}
-			json.Unmarshal([]byte(headersJSON), &w.Headers)
-			if bodyJSON != "" {
-				json.Unmarshal([]byte(bodyJSON), &w.BodyJSON)
-			}
-
-			webhooks = append(webhooks, w)
 		}
-
-		return webhooksLoadedMsg{
-			webhooks:    webhooks,
-			totalCount:  totalCount,
-			currentPage: page,
+		if timestamp == "" {
+			return false
 		}
+		expected := sum([]byte(timestamp), []byte("."), body)
+		matched := 0
+		for _, got := range candidates {
+			matched |= subtle.ConstantTimeCompare(got, expected)
+		}
+		return matched == 1
 	}
+	return false
 }
 
-func initialModel() Model {
-	portInput := textinput.New()
-	portInput.Placeholder = "8098"
-	portInput.Focus()
-	portInput.CharLimit = 5
-	portInput.Width = 20
+// ResponseConfig is a canned response returned to webhook senders
+type ResponseConfig struct {
+	Status      int    `json:"status,omitempty"` // default 200
+	Body        string `json:"body,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+}
 
-	subdomainInput := textinput.New()
-	subdomainInput.Placeholder = "my-webhook-listener"
-	subdomainInput.CharLimit = 50
-	subdomainInput.Width = 30
+// validate rejects statuses net/http can't send and unparseable content types
+func (rc ResponseConfig) validate() error {
+	if rc.Status != 0 && (rc.Status < 100 || rc.Status > 599) {
+		return fmt.Errorf("invalid response status %d", rc.Status)
+	}
+	if rc.ContentType != "" {
+		if _, _, err := mime.ParseMediaType(rc.ContentType); err != nil {
+			return fmt.Errorf("invalid content type %q", rc.ContentType)
+		}
+	}
+	return nil
+}
 
-	timeoutInput := textinput.New()
-	timeoutInput.Placeholder = "30"
-	timeoutInput.CharLimit = 4
-	timeoutInput.Width = 10
+// write sends the response
+func (rc ResponseConfig) write(w http.ResponseWriter) {
+	if rc.ContentType != "" {
+		w.Header().Set("Content-Type", rc.ContentType)
+	}
+	w.WriteHeader(rc.Status)
+	if rc.Body != "" {
+		w.Write([]byte(rc.Body))
+	}
+}
 
-	s := spinner.New()
-	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+// ResponseRule returns Response when the JSON body field satisfies the condition,
+// e.g. {"field": "data.amount", "op": "lt", "value": "0", "response": {"status": 422}}
+type ResponseRule struct {
+	Name     string         `json:"name,omitempty"` // recorded on the webhook, defaults to the condition
+	Path     string         `json:"path,omitempty"` // only for paths with this prefix
+	Field    string         `json:"field"`          // JSON path in the body
+	Op       string         `json:"op"`             // eq, ne, gt, lt, contains or exists
+	Value    string         `json:"value,omitempty"`
+	Response ResponseConfig `json:"response"`
+}
 
-	searchInput := textinput.New()
-	searchInput.Placeholder = ""
-	searchInput.CharLimit = 100
-	searchInput.Width = 30
-	searchInput.Prompt = "/"
+var responseRuleOps = []string{"eq", "ne", "gt", "lt", "contains", "exists"}
 
-	return Model{
-		state:          StateSetup,
-		portInput:      portInput,
-		subdomainInput: subdomainInput,
-		timeoutInput:   timeoutInput,
-		focusedInput:   0,
-		spinner:        s,
-		fetchingIP:     true,
-		webhooks:       make([]WebhookPayload, 0),
-		webhookChan:    make(chan WebhookPayload, 100),
-		viewMode:       ViewModeTable, // Table view by default
-		currentPage:    0,
-		tunnelTimeout:  defaultTunnelTimeout,
-		searchInput:    searchInput,
+// validate reports a rule that can never work as intended
+func (r ResponseRule) validate() error {
+	if r.Field == "" {
+		return fmt.Errorf("field is required")
+	}
+	found := false
+	for _, op := range responseRuleOps {
+		if r.Op == op {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("unknown op %q (use %s)", r.Op, strings.Join(responseRuleOps, ", "))
+	}
+	if r.Op == "gt" || r.Op == "lt" {
+		if _, err := strconv.ParseFloat(r.Value, 64); err != nil {
+			return fmt.Errorf("op %s needs a numeric value, got %q", r.Op, r.Value)
+		}
 	}
+	return r.Response.validate()
 }
 
-func (m Model) Init() tea.Cmd {
-	return tea.Batch(
-		textinput.Blink,
-		m.spinner.Tick,
-		fetchPublicIP,
-		loadWebhooksFromDB(0), // Load previous webhooks on startup
-	)
+// name identifies the rule on webhooks it fired for
+func (r ResponseRule) name() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s", r.Field, r.Op, r.Value))
 }
 
-// Commands
-func fetchPublicIP() tea.Msg {
-	resp, err := http.Get("https://api.ipify.org")
+func (r ResponseRule) matches(wh WebhookPayload) bool {
+	if r.Path != "" && !strings.HasPrefix(wh.Path, r.Path) {
+		return false
+	}
+	value, err := lookupJSONPath(wh.BodyJSON, r.Field)
 	if err != nil {
-		// Try backup service
-		resp, err = http.Get("https://ifconfig.me/ip")
-		if err != nil {
-			return publicIPErrMsg(err)
-		}
+		return false
+	}
+	if r.Op == "exists" {
+		return true
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return publicIPErrMsg(err)
+	str := jsonPathString(wh.BodyJSON, r.Field)
+	switch r.Op {
+	case "eq":
+		return str == r.Value
+	case "ne":
+		return str != r.Value
+	case "contains":
+		return strings.Contains(str, r.Value)
+	case "gt", "lt":
+		n, err := strconv.ParseFloat(fmt.Sprint(value), 64)
+		want, err2 := strconv.ParseFloat(r.Value, 64)
+		if err != nil || err2 != nil {
+			return false
+		}
+		if r.Op == "gt" {
+			return n > want
+		}
+		return n < want
 	}
+	return false
+}
 
-	return publicIPMsg(strings.TrimSpace(string(body)))
+// AlertRule matches webhooks worth an alert. Empty fields match anything,
+// but at least one must be set.
+type AlertRule struct {
+	Name         string `json:"name,omitempty"`          // shown in the alert, defaults to the condition
+	Method       string `json:"method,omitempty"`        // e.g. "POST"
+	Path         string `json:"path,omitempty"`          // path prefix
+	BodyContains string `json:"body_contains,omitempty"` // substring of the raw body
+	Flash        bool   `json:"flash,omitempty"`         // flash the title bar instead of ringing the bell
 }
 
-func startTunnel(port, subdomain string) tea.Cmd {
-	return func() tea.Msg {
-		args := []string{"localtunnel", "--port", port}
-		if subdomain != "" {
-			args = append(args, "--subdomain", subdomain)
-		}
+func (r AlertRule) validate() error {
+	if r.Method == "" && r.Path == "" && r.BodyContains == "" {
+		return fmt.Errorf("needs method, path or body_contains")
+	}
+	return nil
+}
 
-		cmd := exec.Command("npx", args...)
-		// Set process group so we can kill all children on exit
-		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			return tunnelErrorMsg(fmt.Sprintf("Failed to create stdout pipe: %v", err))
+// name identifies the rule in alerts
+func (r AlertRule) name() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	cond := strings.TrimSpace(r.Method + " " + r.Path)
+	if r.BodyContains != "" {
+		cond = strings.TrimSpace(fmt.Sprintf("%s body contains %q", cond, r.BodyContains))
+	}
+	return cond
+}
+
+func (r AlertRule) matches(wh WebhookPayload) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, wh.Method) {
+		return false
+	}
+	if r.Path != "" && !strings.HasPrefix(wh.Path, r.Path) {
+		return false
+	}
+	return r.BodyContains == "" || strings.Contains(wh.Body, r.BodyContains)
+}
+
+// alertFor returns the first alert rule matching a webhook
+func alertFor(wh WebhookPayload) (AlertRule, bool) {
+	for _, rule := range config.AlertRules {
+		if rule.matches(wh) {
+			return rule, true
 		}
+	}
+	return AlertRule{}, false
+}
 
-		if err := cmd.Start(); err != nil {
-			return tunnelErrorMsg(fmt.Sprintf("Failed to start localtunnel: %v", err))
+// validate checks settings that would otherwise fail silently at runtime
+func (c Config) validate() error {
+	if c.ActiveHours != nil {
+		if err := c.ActiveHours.validate(); err != nil {
+			return fmt.Errorf("active_hours: %w", err)
+		}
+	}
+	for prefix, contentType := range c.ContentTypeOverrides {
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			return fmt.Errorf("content_type_overrides[%q]: invalid content type %q", prefix, contentType)
+		}
+	}
+	for i, rule := range c.ResponseRules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("response_rules[%d]: %w", i, err)
+		}
+	}
+	for i, rule := range c.AlertRules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("alert_rules[%d]: %w", i, err)
+		}
+	}
+	if c.HARHost != "" {
+		if u, err := url.Parse(c.HARHost); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("har_host: %q needs a scheme and host, e.g. https://hooks.example.com", c.HARHost)
+		}
+	}
+	if c.ForwardTo != "" {
+		if u, err := url.Parse(c.ForwardTo); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("forward_to: %q needs a scheme and host, e.g. http://localhost:3000", c.ForwardTo)
+		}
+	}
+	if c.RetainDays < 0 {
+		return fmt.Errorf("retain_days: must not be negative")
+	}
+	if c.PageSize < 0 || c.PageSize > maxPageSize {
+		return fmt.Errorf("page_size: must be between 1 and %d", maxPageSize)
+	}
+	if c.APIPort < 0 || c.APIPort > 65535 {
+		return fmt.Errorf("api_port: invalid port %d", c.APIPort)
+	}
+	for method, rc := range c.MethodResponses {
+		if err := rc.validate(); err != nil {
+			return fmt.Errorf("method_responses[%q]: %w", method, err)
+		}
+	}
+	if c.DefaultResponse != nil {
+		if err := c.DefaultResponse.validate(); err != nil {
+			return fmt.Errorf("default_response: %w", err)
 		}
+	}
+	for provider := range c.SigningSecrets {
+		if _, ok := signatureHeaders[provider]; !ok {
+			return fmt.Errorf("signing_secrets: unknown provider %q (github, shopify or stripe)", provider)
+		}
+	}
+	return nil
+}
 
-		// Read the URL from stdout
-		buf := make([]byte, 1024)
-		n, err := stdout.Read(buf)
-		if err != nil {
-			return tunnelErrorMsg(fmt.Sprintf("Failed to read tunnel URL: %v", err))
+// redacted returns a copy of c with the signing secret values masked, for
+// showing the config on screen
+func (c Config) redacted() Config {
+	if len(c.SigningSecrets) > 0 {
+		masked := make(map[string]string, len(c.SigningSecrets))
+		for provider := range c.SigningSecrets {
+			masked[provider] = "••••"
 		}
+		c.SigningSecrets = masked
+	}
+	return c
+}
 
-		output := string(buf[:n])
-		// Parse out the URL from localtunnel output
-		// Output typically looks like: "your url is: https://xxx.loca.lt"
-		url := output
-		if idx := strings.Index(output, "https://"); idx != -1 {
-			url = strings.TrimSpace(output[idx:])
-			if newline := strings.Index(url, "\n"); newline != -1 {
-				url = url[:newline]
+// responseForWebhook applies the first matching response rule, falling back
+// to responseFor. Returns the name of the rule that fired, if any.
+func responseForWebhook(wh WebhookPayload) (ResponseConfig, string) {
+	for _, rule := range config.ResponseRules {
+		if rule.matches(wh) {
+			rc := rule.Response
+			if rc.Status == 0 {
+				rc.Status = http.StatusOK
 			}
+			return rc, rule.name()
 		}
-
-		return tunnelStartedMsg{url: url, cmd: cmd}
 	}
+	return responseFor(wh.Method), ""
 }
 
-func (m *Model) startWebhookServer() tea.Cmd {
-	return func() tea.Msg {
-		port := m.portInput.Value()
-		if port == "" {
-			port = "8098"
+// responseFor picks the configured response for a method, falling back to
+// the global default and then to a plain 200 "OK"
+func responseFor(method string) ResponseConfig {
+	rc, ok := config.MethodResponses[strings.ToUpper(method)]
+	if !ok {
+		if config.DefaultResponse == nil {
+			return ResponseConfig{Status: http.StatusOK, Body: "OK"}
 		}
+		rc = *config.DefaultResponse
+	}
+	if rc.Status == 0 {
+		rc.Status = http.StatusOK
+	}
+	return rc
+}
 
-		webhookChan := m.webhookChan
-		counter := 0
-		counterMu := &sync.Mutex{}
+// ColorRule colors webhooks matching all of its set conditions
+type ColorRule struct {
+	Color       string `json:"color"`                  // name (e.g. "green") or lipgloss color ("82", "#ff8800")
+	Label       string `json:"label,omitempty"`        // legend text, defaults to a description of the rule
+	Method      string `json:"method,omitempty"`       // exact method, case-insensitive
+	Path        string `json:"path,omitempty"`         // path prefix
+	Header      string `json:"header,omitempty"`       // header that must be present...
+	HeaderValue string `json:"header_value,omitempty"` // ...with this value, if set
+	JSONField   string `json:"json_field,omitempty"`   // JSON path in the body, as in json_columns...
+	JSONValue   string `json:"json_value,omitempty"`   // ...with this value, if set
+}
 
-		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			body, err := io.ReadAll(r.Body)
-			if err != nil {
-				http.Error(w, "Failed to read body", http.StatusBadRequest)
-				return
-			}
-			defer r.Body.Close()
+// colorNames maps friendly names to ANSI colors
+var colorNames = map[string]string{
+	"red":     "196",
+	"green":   "82",
+	"yellow":  "226",
+	"orange":  "214",
+	"blue":    "39",
+	"magenta": "205",
+	"cyan":    "51",
+	"gray":    "245",
+	"white":   "255",
+}
 
-			counterMu.Lock()
-			counter++
-			id := counter
-			counterMu.Unlock()
+func (r ColorRule) style() lipgloss.Style {
+	color := r.Color
+	if c, ok := colorNames[strings.ToLower(color)]; ok {
+		color = c
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+}
 
-			headers := make(map[string]string)
-			for k, v := range r.Header {
-				headers[k] = strings.Join(v, ", ")
-			}
+// label is the legend text for the rule
+func (r ColorRule) label() string {
+	if r.Label != "" {
+		return r.Label
+	}
+	var parts []string
+	if r.Method != "" {
+		parts = append(parts, strings.ToUpper(r.Method))
+	}
+	if r.Path != "" {
+		parts = append(parts, r.Path)
+	}
+	if r.Header != "" {
+		parts = append(parts, strings.TrimSuffix(r.Header+"="+r.HeaderValue, "="))
+	}
+	if r.JSONField != "" {
+		parts = append(parts, strings.TrimSuffix(r.JSONField+"="+r.JSONValue, "="))
+	}
+	return strings.Join(parts, " ")
+}
 
-			payload := WebhookPayload{
-				ID:        id,
-				Timestamp: time.Now(),
-				Method:    r.Method,
-				Path:      r.URL.Path,
-				Headers:   headers,
-				Body:      string(body),
-			}
+func (r ColorRule) matches(wh WebhookPayload) bool {
+	if r.Method != "" && !strings.EqualFold(r.Method, wh.Method) {
+		return false
+	}
+	if r.Path != "" && !strings.HasPrefix(wh.Path, r.Path) {
+		return false
+	}
+	if r.Header != "" {
+		value, ok := wh.Headers[http.CanonicalHeaderKey(r.Header)]
+		if !ok || (r.HeaderValue != "" && value != r.HeaderValue) {
+			return false
+		}
+	}
+	if r.JSONField != "" {
+		_, err := lookupJSONPath(wh.BodyJSON, r.JSONField)
+		if err != nil || (r.JSONValue != "" && jsonPathString(wh.BodyJSON, r.JSONField) != r.JSONValue) {
+			return false
+		}
+	}
+	return true
+}
 
-			// Try to parse body as JSON for pretty display
-			var jsonBody interface{}
-			if err := json.Unmarshal(body, &jsonBody); err == nil {
-				payload.BodyJSON = jsonBody
-			}
+// colorRuleFor returns the first color rule matching the webhook, or nil
+func colorRuleFor(wh WebhookPayload) *ColorRule {
+	for i := range config.ColorRules {
+		if config.ColorRules[i].matches(wh) {
+			return &config.ColorRules[i]
+		}
+	}
+	return nil
+}
 
-			// Save to database
-			saveWebhookToDB(payload)
+// ActiveHours is a daily window such as 09:00-18:00, optionally limited to
+// some weekdays. A window ending before it starts runs past midnight and
+// belongs to the day it starts on.
+type ActiveHours struct {
+	Start string   `json:"start"`          // "15:04"
+	End   string   `json:"end"`            // "15:04"
+	Days  []string `json:"days,omitempty"` // "mon".."sun", empty for every day
+}
 
-			select {
-			case webhookChan <- payload:
-			default:
-				// Channel full, drop oldest
-			}
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
 
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("OK"))
-		})
+func (a ActiveHours) validate() error {
+	for _, clock := range []string{a.Start, a.End} {
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return fmt.Errorf("invalid time %q (use HH:MM)", clock)
+		}
+	}
+	if a.Start == a.End {
+		return fmt.Errorf("start and end are both %s", a.Start)
+	}
+	for _, d := range a.Days {
+		if _, ok := weekdayNames[strings.ToLower(d)]; !ok {
+			return fmt.Errorf("unknown day %q", d)
+		}
+	}
+	return nil
+}
 
-		go func() {
-			if err := http.ListenAndServe(":"+port, nil); err != nil {
-				// Server error - in production we'd send this as a message
-			}
-		}()
+// onDay reports whether a window may start on t's weekday
+func (a ActiveHours) onDay(t time.Time) bool {
+	if len(a.Days) == 0 {
+		return true
+	}
+	for _, d := range a.Days {
+		if weekdayNames[strings.ToLower(d)] == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
 
-		return serverStartedMsg{}
+// windowOn returns the window starting on t's day, in t's location
+func (a ActiveHours) windowOn(t time.Time) (start, end time.Time) {
+	startClock, _ := time.Parse("15:04", a.Start)
+	endClock, _ := time.Parse("15:04", a.End)
+	y, mo, d := t.Date()
+	start = time.Date(y, mo, d, startClock.Hour(), startClock.Minute(), 0, 0, t.Location())
+	end = time.Date(y, mo, d, endClock.Hour(), endClock.Minute(), 0, 0, t.Location())
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
 	}
+	return start, end
 }
 
-func waitForWebhook(ch chan WebhookPayload) tea.Cmd {
-	return func() tea.Msg {
-		payload := <-ch
-		return webhookReceivedMsg(payload)
+// at reports whether t is inside a window, and when that changes next
+func (a ActiveHours) at(t time.Time) (active bool, next time.Time) {
+	// Yesterday's window may run past midnight; a week ahead always finds
+	// the next start when any day is allowed
+	for i := -1; i <= 7; i++ {
+		day := t.AddDate(0, 0, i)
+		if !a.onDay(day) {
+			continue
+		}
+		start, end := a.windowOn(day)
+		if !t.Before(start) && t.Before(end) {
+			return true, end
+		}
+		if start.After(t) {
+			return false, start
+		}
 	}
+	return false, time.Time{}
 }
 
-func scheduleTunnelExpiration(timeout time.Duration) tea.Cmd {
-	return tea.Tick(timeout, func(t time.Time) tea.Msg {
-		return tunnelExpiredMsg{}
+// activeHoursCheckInterval is how often the clock is compared to the window
+const activeHoursCheckInterval = 30 * time.Second
+
+func scheduleActiveHoursCheck() tea.Cmd {
+	return tea.Tick(activeHoursCheckInterval, func(time.Time) tea.Msg {
+		return activeHoursTickMsg{}
 	})
 }
 
-func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmds []tea.Cmd
+// CaptureFilter decides which requests are captured. Empty fields match everything.
+type CaptureFilter struct {
+	Methods []string          `json:"methods,omitempty"`
+	Paths   []string          `json:"paths,omitempty"`   // path prefixes
+	Headers map[string]string `json:"headers,omitempty"` // required value, or "" to only require presence
+}
 
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		// Handle search mode input first
-		if m.searchMode {
-			switch msg.String() {
-			case "enter":
-				// Execute search
-				m.searchMode = false
-				m.searchQuery = m.searchInput.Value()
-				m.searchInput.Blur()
-				if m.searchQuery != "" {
-					m.findSearchMatches()
-					m.updateDetailViewport() // Re-render with highlighting
-					if len(m.searchMatches) > 0 {
-						m.searchMatchIdx = 0
-						m.viewport.SetYOffset(m.searchMatches[0])
-					}
-					cmds = append(cmds, tea.ClearScreen)
-				}
-				return m, tea.Batch(cmds...)
-			case "esc":
-				// Cancel search
-				m.searchMode = false
-				m.searchInput.Blur()
-				m.searchInput.SetValue("")
-				// Clear highlighting
-				m.searchQuery = ""
-				m.searchMatches = nil
-				m.updateDetailViewport()
-				cmds = append(cmds, tea.ClearScreen)
-				return m, tea.Batch(cmds...)
-			default:
-				// Pass to search input
-				var cmd tea.Cmd
-				m.searchInput, cmd = m.searchInput.Update(msg)
-				return m, cmd
+// matches reports whether the request should be captured
+func (c CaptureFilter) matches(r *http.Request) bool {
+	if len(c.Methods) > 0 {
+		found := false
+		for _, m := range c.Methods {
+			if strings.EqualFold(m, r.Method) {
+				found = true
+				break
 			}
 		}
+		if !found {
+			return false
+		}
+	}
 
-		switch msg.String() {
-		case "ctrl+c", "q":
-			if m.tunnelCmd != nil && m.tunnelCmd.Process != nil {
-				// Kill the process group to also kill child processes
-				syscall.Kill(-m.tunnelCmd.Process.Pid, syscall.SIGTERM)
-				m.tunnelCmd.Process.Kill()
+	if len(c.Paths) > 0 {
+		found := false
+		for _, p := range c.Paths {
+			if strings.HasPrefix(r.URL.Path, p) {
+				found = true
+				break
 			}
-			return m, tea.Quit
+		}
+		if !found {
+			return false
+		}
+	}
 
-		case "tab", "shift+tab":
-			if m.state == StateSetup {
-				if msg.String() == "shift+tab" {
-					m.focusedInput = (m.focusedInput + 2) % 3 // Go backwards
-				} else {
-					m.focusedInput = (m.focusedInput + 1) % 3
-				}
-				// Update focus states
-				m.portInput.Blur()
-				m.subdomainInput.Blur()
-				m.timeoutInput.Blur()
-				switch m.focusedInput {
-				case 0:
-					m.portInput.Focus()
-				case 1:
-					m.subdomainInput.Focus()
-				case 2:
-					m.timeoutInput.Focus()
-				}
-			}
+	for name, want := range c.Headers {
+		values, ok := r.Header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return false
+		}
+		if want != "" && strings.Join(values, ", ") != want {
+			return false
+		}
+	}
 
-		case "enter":
-			if m.state == StateSetup {
-				m.state = StateRunning
-				port := m.portInput.Value()
-				if port == "" {
-					port = "8098"
-				}
-				subdomain := m.subdomainInput.Value()
+	return true
+}
 
-				// Parse timeout (default 30 minutes)
-				timeoutStr := m.timeoutInput.Value()
-				if timeoutStr == "" {
-					timeoutStr = "30"
-				}
-				if minutes, err := strconv.Atoi(timeoutStr); err == nil && minutes > 0 {
-					m.tunnelTimeout = time.Duration(minutes) * time.Minute
-				} else {
-					m.tunnelTimeout = defaultTunnelTimeout
-				}
+// loadConfig reads the config file, falling back to defaults if it is missing or malformed
+func loadConfig() Config {
+	var c Config
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return c
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Config{}
+	}
+	if c.StripPathRegex != "" {
+		// An invalid pattern is ignored rather than failing startup
+		c.stripPathRe, _ = regexp.Compile(c.StripPathRegex)
+	}
+	if len(c.MethodResponses) > 0 {
+		// Method keys are matched case-insensitively
+		byMethod := make(map[string]ResponseConfig, len(c.MethodResponses))
+		for method, rc := range c.MethodResponses {
+			byMethod[strings.ToUpper(method)] = rc
+		}
+		c.MethodResponses = byMethod
+	}
+	return c
+}
 
-				// Store for display
-				m.requestedPort = port
-				m.requestedSubdomain = subdomain
-				cmds = append(cmds, startTunnel(port, subdomain))
-				cmds = append(cmds, m.startWebhookServer())
-			} else if m.state == StateRunning && len(m.webhooks) > 0 {
-				m.state = StateDetail
-				// Set viewport content for the selected webhook
-				content := m.buildDetailContent()
-				// Calculate line number gutter width (4 digits + " │ " = 7 chars)
-				m.detailGutterWidth = 4
-				gutterTotal := m.detailGutterWidth + 3 // " │ "
-				// Wrap content to viewport width minus gutter
-				m.detailContent = wrapContent(content, m.viewport.Width-gutterTotal)
-				// Clear any previous search
-				m.searchQuery = ""
-				m.searchMatches = nil
-				m.searchMatchIdx = 0
-				// Set viewport with line numbers
-				m.updateDetailViewport()
-				m.viewport.GotoTop()
-			}
+// Bounds and step for changing the page size with +/-
+const (
+	minPageSize  = 5
+	maxPageSize  = 500
+	pageSizeStep = 5
+)
 
-		case "esc":
-			if m.state == StateDetail {
-				m.state = StateRunning
-				// Clear search when leaving detail view
-				m.searchQuery = ""
-				m.searchMatches = nil
-				m.searchMatchIdx = 0
-			}
+// pageSize is the configured number of webhooks per page
+func (c Config) pageSize() int {
+	if c.PageSize > 0 {
+		return c.PageSize
+	}
+	return defaultPageSize
+}
 
-		case "/":
-			if m.state == StateDetail {
-				m.searchMode = true
-				m.searchInput.SetValue("")
-				m.searchInput.Focus()
-				return m, textinput.Blink
-			}
+// setupDefaults are the config values recording the setup screen. The
+// timeout is stored in whole minutes; any other timeout removes the saved one
+// so the default applies next time.
+func setupDefaults(port, subdomain string, timeout time.Duration, provider string) map[string]interface{} {
+	values := map[string]interface{}{
+		"default_port":            port,
+		"default_subdomain":       subdomain,
+		"default_provider":        provider,
+		"default_timeout_minutes": "",
+	}
+	if timeout%time.Minute == 0 {
+		values["default_timeout_minutes"] = int(timeout / time.Minute)
+	}
+	return values
+}
 
-		case "N":
-			if m.state == StateDetail && len(m.searchMatches) > 0 {
-				// Previous match
-				m.searchMatchIdx = (m.searchMatchIdx - 1 + len(m.searchMatches)) % len(m.searchMatches)
-				m.viewport.SetYOffset(m.searchMatches[m.searchMatchIdx])
-				cmds = append(cmds, tea.ClearScreen)
-			}
+// saveConfigCmd saves values to the config file off the UI goroutine,
+// reporting the outcome as a configSavedMsg.
+func saveConfigCmd(what string, values map[string]interface{}) tea.Cmd {
+	return func() tea.Msg {
+		return configSavedMsg{what: what, err: saveConfigValues(values)}
+	}
+}
 
-		case "up", "k":
-			if m.state == StateRunning && m.selectedIdx > 0 {
-				m.selectedIdx--
-			} else if m.state == StateDetail {
-				m.viewport.LineUp(1)
-				cmds = append(cmds, tea.ClearScreen)
-			}
+// saveConfigValues sets keys in the config file, removing those set to "".
+// Other keys keep their values, but the file is rewritten with keys sorted
+// and two-space indents. A malformed file is left alone rather than
+// overwritten.
+func saveConfigValues(values map[string]interface{}) error {
+	raw := map[string]json.RawMessage{}
+	data, err := os.ReadFile(configPath)
+	if err == nil {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("not updating malformed %s: %w", configPath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
 
-		case "down", "j":
-			if m.state == StateRunning && m.selectedIdx < len(m.webhooks)-1 {
-				m.selectedIdx++
-			} else if m.state == StateDetail {
-				m.viewport.LineDown(1)
-				cmds = append(cmds, tea.ClearScreen)
-			}
+	for key, value := range values {
+		if value == "" {
+			delete(raw, key)
+			continue
+		}
+		raw[key], _ = json.Marshal(value)
+	}
 
-		case "c":
-			if m.state == StateRunning {
-				m.webhooksMu.Lock()
-				m.webhooks = make([]WebhookPayload, 0)
-				m.selectedIdx = 0
-				m.webhooksMu.Unlock()
-			}
+	out, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(configPath, append(out, '\n'), 0644)
+}
 
-		case "t":
-			if m.state == StateRunning {
-				if m.viewMode == ViewModeList {
+// spinnerStyles maps spinner_style config values to bubbles spinners
+var spinnerStyles = map[string]spinner.Spinner{
+	"dot":       spinner.Dot,
+	"line":      spinner.Line,
+	"minidot":   spinner.MiniDot,
+	"jump":      spinner.Jump,
+	"pulse":     spinner.Pulse,
+	"points":    spinner.Points,
+	"globe":     spinner.Globe,
+	"moon":      spinner.Moon,
+	"monkey":    spinner.Monkey,
+	"meter":     spinner.Meter,
+	"hamburger": spinner.Hamburger,
+	"ellipsis":  spinner.Ellipsis,
+}
+
+// spinnerStyle returns the configured spinner, falling back to dot
+func (c Config) spinnerStyle() spinner.Spinner {
+	if sp, ok := spinnerStyles[strings.ToLower(c.SpinnerStyle)]; ok {
+		return sp
+	}
+	return spinner.Dot
+}
+
+// viewMode is the saved view mode; anything but "list" means the table
+func (c Config) viewMode() ViewMode {
+	if c.ViewMode == ViewModeList.String() {
+		return ViewModeList
+	}
+	return ViewModeTable
+}
+
+// clockLayout is the time-of-day format for the list and table
+func (c Config) clockLayout() string {
+	if c.MillisecondTimestamps {
+		return "15:04:05.000"
+	}
+	return "15:04:05"
+}
+
+// relativeAge describes how long ago t was: "just now", "5m ago", "3h ago",
+// "yesterday", "4d ago", then the date. Timestamps slightly ahead of now
+// (clock skew between writers) count as just now.
+func relativeAge(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < -time.Minute:
+		return "future"
+	case d < 10*time.Second:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	}
+
+	t, now = t.Local(), now.Local()
+	day := func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local) }
+	days := int(day(now).Sub(day(t)).Hours()+12) / 24 // DST days are 23 or 25 hours
+	switch {
+	case days == 0:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case days == 1:
+		return "yesterday"
+	case days < 7:
+		return fmt.Sprintf("%dd ago", days)
+	case t.Year() == now.Year():
+		return t.Format("Jan 2")
+	}
+	return t.Format("2006-01-02")
+}
+
+// timestampLayout is the full timestamp format for the detail view
+func (c Config) timestampLayout() string {
+	if c.MillisecondTimestamps {
+		return "2006-01-02T15:04:05.000Z07:00"
+	}
+	return time.RFC3339
+}
+
+// pathsAbbreviated reports whether list/table paths are shortened for display
+func (c Config) pathsAbbreviated() bool {
+	return c.StripPathPrefix != "" || c.stripPathRe != nil
+}
+
+// displayPath shortens a path for the list/table using the configured prefix or regex
+func displayPath(path string) string {
+	short := path
+	if config.StripPathPrefix != "" {
+		short = strings.TrimPrefix(short, config.StripPathPrefix)
+	}
+	if config.stripPathRe != nil {
+		short = config.stripPathRe.ReplaceAllString(short, "")
+	}
+	if short == "" {
+		return "/"
+	}
+	return short
+}
+
+// shownPath is the list/table form of a webhook's path: as sent, or
+// percent-decoded when toggled on
+func (m Model) shownPath(wh WebhookPayload) string {
+	if m.decodePaths {
+		return displayPath(wh.Path)
+	}
+	return displayPath(wh.rawPath())
+}
+
+// terminal is the program's output. Sequences sent outside a frame, like the
+// OSC52 clipboard copy, are written through it as one write each; writes to
+// the terminal file are serialized, so they never split a frame.
+var terminal = termenv.NewOutput(os.Stdout)
+
+// Styles
+var (
+	titleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("205")).
+			Background(lipgloss.Color("235")).
+			Padding(0, 1)
+
+	infoStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241"))
+
+	successStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("82"))
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196"))
+
+	highlightStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("212"))
+
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214"))
+
+	selectedStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("205")).
+			Padding(0, 1)
+
+	webhookItemStyle = lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("240")).
+				Padding(0, 1).
+				MarginBottom(1)
+
+	webhookSelectedStyle = lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("205")).
+				Padding(0, 1).
+				MarginBottom(1)
+
+	headerStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("39")).
+			Bold(true)
+
+	bodyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("252"))
+
+	helpStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Italic(true)
+
+	// JSON syntax highlighting styles
+	jsonKeyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("81")) // cyan
+
+	jsonStringStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("114")) // green
+
+	jsonNumberStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("222")) // yellow
+
+	jsonBoolStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("212")) // magenta
+
+	jsonNullStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("244")) // gray
+
+	jsonBracketStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("252")) // light gray
+
+	lineNumberStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("239")) // dim gray
+
+	processedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("240"))
+
+	searchHighlightStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color("226")). // yellow background
+				Foreground(lipgloss.Color("0"))    // black text
+
+	alertFlashStyle = lipgloss.NewStyle().
+			Bold(true).
+			Background(lipgloss.Color("196")). // red background
+			Foreground(lipgloss.Color("231")). // white text
+			Padding(0, 1)
+)
+
+// WebhookPayload represents an incoming webhook
+type WebhookPayload struct {
+	// ID is the row id in the webhooks table; live webhooks are stored before
+	// they are shown, so live and loaded entries share one stable numbering
+	ID        int               `json:"id"`
+	Timestamp time.Time         `json:"timestamp"`
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Headers   map[string]string `json:"headers"`
+	Body      string            `json:"body"`
+	BodyJSON  interface{}       `json:"body_json,omitempty"`
+
+	DeliveryID string `json:"delivery_id,omitempty"` // provider delivery id, shared by retries
+	RetryNum   int    `json:"retry_num,omitempty"`   // earlier deliveries with the same id
+
+	Parts []MultipartPart `json:"parts,omitempty"` // summary of a multipart/form-data body
+
+	HeaderBytes int `json:"header_bytes,omitempty"` // total size of the received headers
+
+	Processed bool `json:"processed,omitempty"` // marked as handled during triage
+
+	ResponseStatus int `json:"response_status,omitempty"` // status we answered with (0 = not recorded)
+
+	Metadata map[string]string `json:"metadata,omitempty"` // set externally, e.g. with "webhook-tui meta"
+
+	// ChangeSummary compares the body with the previous webhook on the same path:
+	// "unchanged", "changed" or "changed (N fields)"; empty for the first one
+	ChangeSummary string `json:"change_summary,omitempty"`
+
+	ResponseRule string `json:"response_rule,omitempty"` // response rule that picked our reply
+
+	// RawURI is the request target as sent, including the query string;
+	// Proto is the protocol version, e.g. HTTP/1.1
+	RawURI string `json:"raw_uri,omitempty"`
+	Proto  string `json:"proto,omitempty"`
+
+	RunID string `json:"run_id,omitempty"` // WEBHOOK_TUI_RUN_ID of the capturing process
+
+	// Sequence is the provider's sequence number; SequenceExpected is the one
+	// that should have come next on this path (0 for the first one seen)
+	Sequence         string `json:"sequence,omitempty"`
+	SequenceExpected int64  `json:"sequence_expected,omitempty"`
+
+	HeadersDropped int `json:"headers_dropped,omitempty"` // headers not stored for privacy
+
+	Port string `json:"port,omitempty"` // local port the request arrived on ("" for a Unix socket)
+
+	XML bool `json:"xml,omitempty"` // body parsed as XML, shown indented
+
+	// HeaderLines keeps each line of headers sent more than once, such as
+	// several Set-Cookie lines; Headers has them joined with ", ". Rows stored
+	// before it was recorded only have the joined form.
+	HeaderLines map[string][]string `json:"header_lines,omitempty"`
+
+	// Decompressed is the Content-Encoding ("gzip" or "deflate") Body was
+	// decoded from; the header itself is stored unchanged
+	Decompressed string `json:"decompressed,omitempty"`
+
+	// SignatureValid is the result of checking the provider's HMAC signature
+	// with signing_secrets; nil when the request was not verified
+	SignatureProvider string `json:"signature_provider,omitempty"`
+	SignatureValid    *bool  `json:"signature_valid,omitempty"`
+
+	// ForwardStatus is the status forward_to answered with; ForwardError
+	// why forwarding failed. Both are empty when it was not forwarded.
+	ForwardStatus int    `json:"forward_status,omitempty"`
+	ForwardError  string `json:"forward_error,omitempty"`
+}
+
+// maxHeaderBytes is the configured oversized-header threshold
+func maxHeaderBytes() int {
+	if config.MaxHeaderBytes > 0 {
+		return config.MaxHeaderBytes
+	}
+	return defaultMaxHeaderBytes
+}
+
+// oversizedHeaders reports whether the webhook's headers exceeded the threshold
+func (wh WebhookPayload) oversizedHeaders() bool {
+	return wh.HeaderBytes > maxHeaderBytes()
+}
+
+// contentType returns the Content-Type header, whatever case it was stored in
+func (wh WebhookPayload) contentType() string {
+	for k, v := range wh.Headers {
+		if strings.EqualFold(k, "Content-Type") {
+			return v
+		}
+	}
+	return ""
+}
+
+// formFields decodes an application/x-www-form-urlencoded body. The body is
+// stored as sent, so this is done when showing it rather than on arrival.
+func (wh WebhookPayload) formFields() (url.Values, bool) {
+	contentType := contentTypeOverride(wh.Path)
+	if contentType == "" {
+		contentType = wh.contentType()
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "application/x-www-form-urlencoded" {
+		return nil, false
+	}
+	values, err := url.ParseQuery(wh.Body)
+	if err != nil || len(values) == 0 {
+		return nil, false
+	}
+	return values, true
+}
+
+// requestURI is the request target to replay or export; rows captured before
+// it was recorded only have the path
+func (wh WebhookPayload) requestURI() string {
+	if wh.RawURI != "" {
+		return wh.RawURI
+	}
+	return wh.Path
+}
+
+// rawPath is the path as sent, percent-encoding intact. Path holds the decoded
+// form that filters and rules match; rows captured before RawURI get the
+// canonical encoding of it.
+func (wh WebhookPayload) rawPath() string {
+	if wh.RawURI != "" {
+		if u, err := url.ParseRequestURI(wh.RawURI); err == nil {
+			return u.EscapedPath()
+		}
+	}
+	return (&url.URL{Path: wh.Path}).EscapedPath()
+}
+
+// queryParam is one name=value pair of a query string
+type queryParam struct {
+	Name  string
+	Value string
+}
+
+// queryParams decodes the query string of RawURI in the order it was sent,
+// keeping repeated keys; rows captured before RawURI have none. A name or
+// value that doesn't unescape is kept as sent.
+func (wh WebhookPayload) queryParams() []queryParam {
+	var query []queryParam
+	u, err := url.ParseRequestURI(wh.requestURI())
+	if err != nil {
+		return query
+	}
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		query = append(query, queryParam{Name: name, Value: value})
+	}
+	return query
+}
+
+// proto is the recorded protocol version, assuming HTTP/1.1 for older rows
+func (wh WebhookPayload) proto() string {
+	if wh.Proto != "" {
+		return wh.Proto
+	}
+	return "HTTP/1.1"
+}
+
+// headerValues returns each line a header was sent on
+func (wh WebhookPayload) headerValues(name string) []string {
+	if lines, ok := wh.HeaderLines[name]; ok {
+		return lines
+	}
+	return []string{wh.Headers[name]}
+}
+
+// cookies parses the Cookie and Set-Cookie headers. Older rows only have
+// repeated headers joined with ", ", so they are split back into lines first.
+func (wh WebhookPayload) cookies() (sent, set []*http.Cookie) {
+	header := http.Header{}
+	for k, v := range wh.Headers {
+		switch {
+		case strings.EqualFold(k, "Cookie"):
+			header["Cookie"] = strings.Split(v, ", ")
+		case strings.EqualFold(k, "Set-Cookie"):
+			if lines, ok := wh.HeaderLines[k]; ok {
+				header["Set-Cookie"] = lines
+			} else {
+				header["Set-Cookie"] = splitSetCookie(v)
+			}
+		}
+	}
+	sent = (&http.Request{Header: header}).Cookies()
+	set = (&http.Response{Header: header}).Cookies()
+	return sent, set
+}
+
+// splitSetCookie undoes the ", " join of several Set-Cookie headers. A comma
+// also appears inside Expires dates, so a piece only starts a new cookie when
+// it begins with name=value.
+func splitSetCookie(v string) []string {
+	var lines []string
+	for _, piece := range strings.Split(v, ", ") {
+		first, _, _ := strings.Cut(piece, ";")
+		if len(lines) > 0 && !strings.Contains(first, "=") {
+			lines[len(lines)-1] += ", " + piece
+			continue
+		}
+		lines = append(lines, piece)
+	}
+	return lines
+}
+
+// isJSONContentType reports whether a Content-Type declares JSON
+// (application/json or a +json type such as application/vnd.api+json)
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// contentTypeOverride returns the configured content type for a path; the
+// longest matching prefix wins and "*" applies when nothing else does
+func contentTypeOverride(path string) string {
+	best, bestLen := config.ContentTypeOverrides["*"], 0
+	for prefix, contentType := range config.ContentTypeOverrides {
+		if prefix != "*" && strings.HasPrefix(path, prefix) && len(prefix) > bestLen {
+			best, bestLen = contentType, len(prefix)
+		}
+	}
+	return best
+}
+
+// forcedText reports whether an override says the body isn't JSON, so it is
+// shown as sent even if it happens to parse
+func (wh WebhookPayload) forcedText() bool {
+	contentType := contentTypeOverride(wh.Path)
+	return contentType != "" && !isJSONContentType(contentType)
+}
+
+// contentTypeNote explains why a body is shown as JSON although the sender
+// didn't declare it. A parsed body is treated as JSON everywhere regardless
+// of the header; this is empty when the two agree.
+func (wh WebhookPayload) contentTypeNote() string {
+	if override := contentTypeOverride(wh.Path); override != "" {
+		sent := wh.contentType()
+		if sent == "" {
+			sent = "none"
+		}
+		return fmt.Sprintf("Content-Type overridden to %s, sent as %s", override, sent)
+	}
+	if wh.BodyJSON == nil {
+		return ""
+	}
+	contentType := wh.contentType()
+	if contentType == "" {
+		return "no Content-Type header, body parsed as JSON"
+	}
+	if !isJSONContentType(contentType) {
+		return fmt.Sprintf("Content-Type is %s, body parsed as JSON", contentType)
+	}
+	return ""
+}
+
+// decompressBody decodes a gzip or deflate body. It returns the encoding it
+// decoded, "" with the body unchanged for other encodings, and an error for
+// corrupt data or output over maxDecompressedBody.
+func decompressBody(contentEncoding string, body []byte) ([]byte, string, error) {
+	encoding := strings.ToLower(strings.TrimSpace(contentEncoding))
+	var r io.Reader
+	switch encoding {
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, "", err
+		}
+		defer zr.Close()
+		r, encoding = zr, "gzip"
+	case "deflate":
+		// HTTP deflate is zlib-wrapped, but some senders use raw deflate
+		if zr, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+			defer zr.Close()
+			r = zr
+		} else {
+			r = flate.NewReader(bytes.NewReader(body))
+		}
+	default:
+		return body, "", nil
+	}
+
+	out, err := io.ReadAll(io.LimitReader(r, int64(maxDecompressedBody)+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(out) > maxDecompressedBody {
+		return nil, "", fmt.Errorf("decompressed body exceeds %s", formatBytes(maxDecompressedBody))
+	}
+	return out, encoding, nil
+}
+
+// MultipartPart summarizes one part of a multipart/form-data body
+type MultipartPart struct {
+	Name        string `json:"name"`
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int    `json:"size"`
+	Value       string `json:"value,omitempty"` // text fields only, capped at maxPartValue
+}
+
+// State represents the current view/state of the application
+type State int
+
+const (
+	StateSetup State = iota
+	StateRunning
+	StateDetail
+	StateStats
+	StateHistory
+	StateInfo
+	StateCleanup
+	StateSinceMark
+)
+
+// ViewMode represents how webhooks are displayed
+type ViewMode int
+
+const (
+	ViewModeList ViewMode = iota
+	ViewModeTable
+)
+
+// String is the view_mode config value for the mode
+func (v ViewMode) String() string {
+	if v == ViewModeList {
+		return "list"
+	}
+	return "table"
+}
+
+// Model is the main application model
+type Model struct {
+	state          State
+	portInput      textinput.Model
+	subdomainInput textinput.Model
+	timeoutInput   textinput.Model
+	focusedInput   int
+	providerIdx    int // index into tunnelProviders
+	spinner        spinner.Model
+	viewport       viewport.Model
+	viewportReady  bool
+
+	publicIP           string
+	fetchingIP         bool
+	tunnelURL          string
+	tunnelRunning      bool
+	tunnelExpired      bool // true when auto-shutdown occurred
+	tunnelError        string
+	serverRunning      bool
+	serverStopped      bool     // shut down with the expired tunnel, restarted with the tunnel
+	requestedPort      string   // the port the tunnel forwards to, the first of ports
+	ports              []string // every port the server listens on
+	servers            []*http.Server
+	apiServer          *http.Server // read-only JSON API, nil unless api_port is set
+	requestedSubdomain string
+	tunnelTimeout      time.Duration // how long before auto-shutdown
+	tunnelStartTime    time.Time     // when tunnel was started
+	expiryPausedFor    time.Duration // total time the expiry countdown was paused
+	expiryPausedAt     time.Time     // start of the current pause, zero when counting
+	serverError        string
+	publicIPAttempts   int
+	lastActivity       time.Time // last webhook (or server start), for idle auto-quit
+	lastKeyAt          time.Time // last keypress, so auto-open doesn't interrupt navigation
+	oversizedHeaders   int       // webhooks this session with headers above the threshold
+
+	// Readiness: server bound, tunnel URL obtained, request through tunnel verified
+	tunnelVerified bool
+	verifying      bool
+	verifyError    string
+	verifyAttempts int
+
+	// Periodic health check through the tunnel once it has been verified
+	tunnelReachable bool
+	healthFailures  int
+	lastHealthCheck time.Time
+	healthScheduled bool
+
+	outsideHours bool // tunnel held down outside active_hours
+
+	// auto_reconnect: a new tunnel is being started; failures back off up to maxReconnectDelay
+	reconnecting   bool
+	reconnectDelay time.Duration
+
+	spinning bool // a spinner tick is in flight
+	clocking bool // a clockTickMsg is in flight
+
+	traffic rateWindow // webhooks per second, for the sparkline
+
+	webhooks      []WebhookPayload
+	webhooksMu    *sync.Mutex
+	selectedIdx   int
+	webhookChan   chan WebhookPayload
+	overrides     *responseOverrides // per-path status overrides shared with the handler
+	discarded     *atomic.Int64      // requests rejected by the capture filter
+	droppedLive   *atomic.Int64      // stored webhooks not delivered to the live view
+	captured      *atomic.Int64      // requests accepted in strict path mode
+	notFound      *atomic.Int64      // requests 404'd in strict path mode
+	forwardPaused *atomic.Bool       // forward_to paused with F
+	forwardChan   chan forwardResultMsg
+	alertChan     chan alertMsg // alert rule matches from the handler
+	viewMode      ViewMode
+
+	// Pagination
+	currentPage   int
+	pageSize      int // webhooks per page, changed with +/-
+	totalPages    int
+	totalWebhooks int
+
+	// Filtering
+	filter              webhookFilter
+	filterMode          bool
+	filterInput         textinput.Model
+	confirmDeleteFilter bool
+	confirmDeleteID     int  // webhook awaiting delete confirmation, 0 for none
+	confirmPurge        bool // P pressed: delete webhooks older than retain_days?
+	follow              bool // keep the newest webhook selected as new ones arrive
+	decodePaths         bool // show percent-decoded paths
+	autoOpen            bool // open new webhooks in the detail view
+	notify              bool // desktop notifications for new webhooks
+	notifyHeld          bool // a notification went out within notifyWindow
+	notifySuppressed    int  // webhooks held back since, summarized when the window ends
+	notifyLatest        string
+	flashText           string    // alert shown in the title bar while flashing
+	flashUntil          time.Time // when the current flash ends
+	bell                bool      // BEL goes out with the next frames until bellEndMsg
+	mark                *activityMark
+	sinceMark           *sinceMarkSummary
+	baselines           map[string]baseline
+	cleanup             []cleanupBucket
+	cleanupIdx          int
+	confirmCleanup      bool
+
+	statusMsg string // transient message shown above the help line
+	statusErr bool   // render statusMsg as an error
+
+	width  int
+	height int
+
+	tunnelCmd *exec.Cmd
+
+	// Detail rendering options
+	compactJSON bool // render JSON bodies minified on a single line
+	arrayMode   bool // page through top-level JSON arrays element by element
+	arrayPage   int
+	hexMode     bool // show binary bodies as a hex+ASCII dump
+
+	// Stats view
+	stats       *statsData
+	statsMetric int // index into histogramMetrics
+
+	history         []historyEntry
+	info            *dbInfo
+	detailTunnelURL string // tunnel URL that was active when the detail webhook arrived
+
+	// Search in detail view
+	searchMode        bool
+	searchInput       textinput.Model
+	searchQuery       string
+	searchRegex       bool           // read queries as regular expressions (ctrl+r while typing)
+	searchRe          *regexp.Regexp // compiled searchQuery
+	searchErr         string         // why the typed query can't be searched
+	searchMatches     []int          // line numbers with matches
+	searchMatchIdx    int            // current match index
+	detailContent     string         // raw content for searching
+	detailGutterWidth int            // gutter width for line numbers
+
+	// jq filter over the detail body
+	jqMode   bool
+	jqInput  textinput.Model
+	jqExpr   string // last expression, offered again next time
+	jqResult string // output shown instead of the details, "" when not showing
+
+	// Built-in path query (:) over the detail body; shares jqResult
+	pathMode    bool
+	pathInput   textinput.Model
+	pathExpr    string
+	resultTitle string // what produced jqResult, e.g. "jq .data"
+
+	// Replaying the selected webhook to another URL
+	replayMode   bool
+	replayInput  textinput.Model
+	replayTarget string // last target, offered again next time
+
+	// Command palette (ctrl+p)
+	paletteMode  bool
+	paletteInput textinput.Model
+	paletteIdx   int
+}
+
+// Messages
+type publicIPMsg string
+type publicIPErrMsg error
+type tunnelStartedMsg struct {
+	url    string
+	cmd    *exec.Cmd
+	exited <-chan error
+}
+
+// tunnelDiedMsg is sent when a tunnel process exits; cmd tells a stale or
+// deliberately stopped tunnel apart from the current one crashing
+type tunnelDiedMsg struct {
+	cmd *exec.Cmd
+	err error
+}
+type tunnelErrorMsg string
+
+// forwardResultMsg is the outcome of mirroring webhook id to forward_to
+type forwardResultMsg struct {
+	id     int
+	status int
+	err    string
+}
+
+// alertMsg reports a webhook that matched an alert rule
+type alertMsg struct {
+	rule  AlertRule
+	id    int
+	title string // method and path
+}
+type flashEndMsg struct{}
+type bellEndMsg struct{}
+type serverStartedMsg struct{ servers []*http.Server }
+type serversStoppedMsg struct{}
+type serverErrorMsg string
+type apiStartedMsg struct{ server *http.Server }
+type apiErrorMsg string
+type tunnelVerifiedMsg struct{}
+type tunnelVerifyFailedMsg string
+type retryVerifyMsg struct{}
+type healthCheckTickMsg struct{}
+type activeHoursTickMsg struct{}
+type reconnectMsg struct{}
+type clockTickMsg struct{}
+type healthCheckMsg struct{ err error }
+type idleCheckMsg struct{}
+type retryPublicIPMsg struct{}
+type webhookReceivedMsg WebhookPayload
+type webhooksLoadedMsg struct {
+	webhooks    []WebhookPayload
+	totalCount  int
+	currentPage int
+}
+type dbErrorMsg string
+type tunnelExpiredMsg struct{}
+type webhookDeletedMsg struct{ id int }
+type webhooksDeletedMsg struct {
+	count int64
+}
+type exportDoneMsg struct {
+	path  string
+	count int
+}
+type exportErrorMsg string
+type statsLoadedMsg *statsData
+type historyLoadedMsg []historyEntry
+type infoLoadedMsg *dbInfo
+type cleanupLoadedMsg []cleanupBucket
+type markSetMsg activityMark
+type sinceMarkLoadedMsg *sinceMarkSummary
+type baselinesLoadedMsg map[string]baseline
+type cleanupDoneMsg struct {
+	label     string
+	count     int64
+	reclaimed int64
+}
+type startupPurgedMsg cleanupDoneMsg
+
+// dbInfo describes where data lives and how big it has grown
+type dbInfo struct {
+	path     string
+	fileSize int64
+	rows     int
+	oldest   string
+	newest   string
+	runRows  int // webhooks captured under the current run id
+}
+type jqResultMsg struct {
+	output string
+	err    error
+}
+type detailReplayMsg struct {
+	id     int
+	target string
+	result replayResult
+	err    error
+}
+type notifyWindowMsg struct{}
+type notifyFailedMsg string
+type configSavedMsg struct {
+	what string // setting saved, e.g. "page size"
+	err  error
+}
+type detailTunnelURLMsg struct {
+	id  int // webhook the URL was looked up for
+	url string
+}
+type clipboardMsg struct {
+	what string // description for the confirmation, e.g. "markdown"
+	err  error
+}
+
+// historyEntry is one tunnel URL assignment recorded in the sessions table
+type historyEntry struct {
+	startedAt time.Time
+	tunnelURL string
+	publicIP  string
+	port      string
+	webhooks  int // webhooks received while this URL was the latest
+}
+
+// histogramMetric is a per-webhook size that can be histogrammed in the stats view
+type histogramMetric struct {
+	name string
+	expr string // SQL expression evaluated per row
+}
+
+var histogramMetrics = []histogramMetric{
+	{"Body size", "LENGTH(body)"},
+	{"Header size", "LENGTH(headers)"},
+}
+
+// histogramBounds are the upper bounds (exclusive) of the size buckets, in bytes
+var histogramBounds = []int{1, 100, 1 << 10, 10 << 10, 100 << 10, 1 << 20}
+
+type histogramBucket struct {
+	label string
+	count int
+}
+
+// cleanupBucket is one age range on the cleanup screen
+type cleanupBucket struct {
+	label    string
+	from, to time.Time // zero = unbounded
+	count    int
+	size     int64 // bytes of headers and bodies
+}
+
+// cleanupBuckets splits stored webhooks into today, this week and older
+func cleanupBuckets(now time.Time) []cleanupBucket {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	week := today.AddDate(0, 0, -6)
+	return []cleanupBucket{
+		{label: "Today", from: today},
+		{label: "This week", from: week, to: today},
+		{label: "Older", to: week},
+	}
+}
+
+// retentionBucket holds the webhooks retain_days says to delete
+func retentionBucket(days int, now time.Time) cleanupBucket {
+	return cleanupBucket{label: fmt.Sprintf("Older than %d days", days), to: now.AddDate(0, 0, -days)}
+}
+
+// whereClause selects the bucket's rows; cutoffs are formatted with dbTime
+// so they compare as text against stored timestamps
+func (b cleanupBucket) whereClause() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if !b.from.IsZero() {
+		conds = append(conds, "timestamp >= ?")
+		args = append(args, dbTime(b.from))
+	}
+	if !b.to.IsZero() {
+		conds = append(conds, "timestamp < ?")
+		args = append(args, dbTime(b.to))
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// statsData is the aggregate view of the stored webhooks
+type statsData struct {
+	metric    string
+	histogram []histogramBucket
+
+	total    int
+	earliest string
+	latest   string
+	session  int               // webhooks received since this process started
+	methods  []histogramBucket // counts per method, most frequent first
+	statuses []histogramBucket // counts per response status
+}
+
+// responseOverrides maps request paths to a status code the server returns
+// instead of its default. Toggled from the UI, read by the HTTP handler.
+type responseOverrides struct {
+	mu     sync.Mutex
+	byPath map[string]int
+}
+
+func newResponseOverrides() *responseOverrides {
+	return &responseOverrides{byPath: make(map[string]int)}
+}
+
+func (o *responseOverrides) get(path string) (int, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	status, ok := o.byPath[path]
+	return status, ok
+}
+
+// toggle sets the override for path, or removes it if one is already set.
+// Returns true if the override is now active.
+func (o *responseOverrides) toggle(path string, status int) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.byPath[path]; ok {
+		delete(o.byPath, path)
+		return false
+	}
+	o.byPath[path] = status
+	return true
+}
+
+// String lists active overrides sorted by path, e.g. "/a→500, /b→500"
+func (o *responseOverrides) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	paths := make([]string, 0, len(o.byPath))
+	for p := range o.byPath {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	parts := make([]string, len(paths))
+	for i, p := range paths {
+		parts[i] = fmt.Sprintf("%s→%d", p, o.byPath[p])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// methodFilterCycle is the order M steps through, "" meaning all methods
+var methodFilterCycle = []string{"", "GET", "POST", "PUT", "DELETE", "PATCH"}
+
+// nextMethodFilter returns the method after current in methodFilterCycle.
+// A method typed into the filter that isn't in the cycle goes back to all.
+func nextMethodFilter(current string) string {
+	for i, method := range methodFilterCycle {
+		if method == current {
+			return methodFilterCycle[(i+1)%len(methodFilterCycle)]
+		}
+	}
+	return ""
+}
+
+// webhookFilter holds the active list filters. Loading, counting, deleting
+// and the filter summary are all built from its clauses so they never disagree.
+type webhookFilter struct {
+	Path   string        // case-insensitive substring match on the request path
+	Method string        // exact HTTP method
+	Since  time.Duration // only webhooks received within this window
+	Body   string        // case-insensitive substring match on the body
+
+	HideProcessed bool // hide webhooks marked as processed
+
+	MetaKey   string // only webhooks with this metadata key...
+	MetaValue string // ...set to this value ("" = any value)
+
+	RunID string // exact run id the webhook was captured under
+
+	JSONPath  string // only webhooks whose JSON body has this path, e.g. "data.type"...
+	JSONValue string // ...with this value ("" = any value)
+
+	Regex string // regular expression matched against the path, headers or body
+}
+
+// filterClause is one active filter condition
+type filterClause struct {
+	label string
+	sql   string
+	args  []interface{}
+	match func(wh WebhookPayload) bool
+	clear func(f *webhookFilter)
+}
+
+// likeContains is a LIKE pattern matching s anywhere, with its own % and _
+// taken literally; clauses using it need ESCAPE '\'
+func likeContains(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+// clauses lists the active filter conditions in display order
+func (f webhookFilter) clauses() []filterClause {
+	var cs []filterClause
+
+	if f.Path != "" {
+		cs = append(cs, filterClause{
+			label: "path~" + f.Path,
+			sql:   `path LIKE ? ESCAPE '\'`,
+			args:  []interface{}{likeContains(f.Path)},
+			match: func(wh WebhookPayload) bool { return containsFold(wh.Path, f.Path) },
+			clear: func(f *webhookFilter) { f.Path = "" },
+		})
+	}
+	if f.Method != "" {
+		cs = append(cs, filterClause{
+			label: "method=" + f.Method,
+			sql:   "method = ?",
+			args:  []interface{}{f.Method},
+			match: func(wh WebhookPayload) bool { return wh.Method == f.Method },
+			clear: func(f *webhookFilter) { f.Method = "" },
+		})
+	}
+	if f.Since > 0 {
+		cutoff := time.Now().Add(-f.Since)
+		cs = append(cs, filterClause{
+			label: "since " + f.Since.String(),
+			sql:   "timestamp >= ?",
+			args:  []interface{}{dbTime(cutoff)},
+			match: func(wh WebhookPayload) bool { return !wh.Timestamp.Before(cutoff) },
+			clear: func(f *webhookFilter) { f.Since = 0 },
+		})
+	}
+	if f.Body != "" {
+		cs = append(cs, filterClause{
+			label: "body~" + f.Body,
+			sql:   `body LIKE ? ESCAPE '\'`,
+			args:  []interface{}{likeContains(f.Body)},
+			match: func(wh WebhookPayload) bool { return containsFold(wh.Body, f.Body) },
+			clear: func(f *webhookFilter) { f.Body = "" },
+		})
+	}
+	if f.MetaKey != "" {
+		// Quote the key so dots and spaces aren't read as JSON path syntax
+		jsonPath := `$."` + strings.ReplaceAll(f.MetaKey, `"`, `\"`) + `"`
+		c := filterClause{
+			label: "meta:" + f.MetaKey,
+			sql:   "json_extract(NULLIF(metadata, ''), ?) IS NOT NULL",
+			args:  []interface{}{jsonPath},
+			match: func(wh WebhookPayload) bool { _, ok := wh.Metadata[f.MetaKey]; return ok },
+			clear: func(f *webhookFilter) { f.MetaKey, f.MetaValue = "", "" },
+		}
+		if f.MetaValue != "" {
+			c.label += "=" + f.MetaValue
+			c.sql = "json_extract(NULLIF(metadata, ''), ?) = ?"
+			c.args = append(c.args, f.MetaValue)
+			c.match = func(wh WebhookPayload) bool { return wh.Metadata[f.MetaKey] == f.MetaValue }
+		}
+		cs = append(cs, c)
+	}
+	if f.JSONPath != "" {
+		c := filterClause{
+			label: "json:" + f.JSONPath,
+			sql:   "json_path_text(body_json, ?) IS NOT NULL",
+			args:  []interface{}{f.JSONPath},
+			match: func(wh WebhookPayload) bool { _, err := lookupJSONPath(wh.BodyJSON, f.JSONPath); return err == nil },
+			clear: func(f *webhookFilter) { f.JSONPath, f.JSONValue = "", "" },
+		}
+		if f.JSONValue != "" {
+			c.label += "=" + f.JSONValue
+			c.sql = "json_path_text(body_json, ?) = ?"
+			c.args = []interface{}{f.JSONPath, f.JSONValue}
+			c.match = func(wh WebhookPayload) bool { return jsonPathString(wh.BodyJSON, f.JSONPath) == f.JSONValue }
+		}
+		cs = append(cs, c)
+	}
+	if f.RunID != "" {
+		cs = append(cs, filterClause{
+			label: "run=" + f.RunID,
+			sql:   "run_id = ?",
+			args:  []interface{}{f.RunID},
+			match: func(wh WebhookPayload) bool { return wh.RunID == f.RunID },
+			clear: func(f *webhookFilter) { f.RunID = "" },
+		})
+	}
+	if f.Regex != "" {
+		// An invalid pattern (possible from the CLI flag) fails the query
+		// in the REGEXP function rather than matching everything
+		re, err := regexp.Compile(f.Regex)
+		cs = append(cs, filterClause{
+			label: "re/" + f.Regex + "/",
+			sql:   "(path REGEXP ? OR headers REGEXP ? OR body REGEXP ?)",
+			args:  []interface{}{f.Regex, f.Regex, f.Regex},
+			match: func(wh WebhookPayload) bool {
+				if err != nil {
+					return false
+				}
+				headers, _ := json.Marshal(wh.Headers) // as stored
+				return re.MatchString(wh.Path) || re.Match(headers) || re.MatchString(wh.Body)
+			},
+			clear: func(f *webhookFilter) { f.Regex = "" },
+		})
+	}
+	if f.HideProcessed {
+		cs = append(cs, filterClause{
+			label: "unprocessed",
+			sql:   "processed = 0",
+			match: func(wh WebhookPayload) bool { return !wh.Processed },
+			clear: func(f *webhookFilter) { f.HideProcessed = false },
+		})
+	}
+
+	return cs
+}
+
+// highlight is the text search to highlight in the detail view: the re:
+// pattern, else the body: or path substring
+func (f webhookFilter) highlight() (query string, regex bool) {
+	switch {
+	case f.Regex != "":
+		return f.Regex, true
+	case f.Body != "":
+		return f.Body, false
+	}
+	return f.Path, false
+}
+
+func (f webhookFilter) active() bool {
+	return len(f.clauses()) > 0
+}
+
+// whereClause returns the SQL WHERE clause (empty when no filter is active) and its args
+func (f webhookFilter) whereClause() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+
+	for _, c := range f.clauses() {
+		conds = append(conds, c.sql)
+		args = append(args, c.args...)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// matches reports whether a live webhook passes the filter, mirroring whereClause
+func (f webhookFilter) matches(wh WebhookPayload) bool {
+	for _, c := range f.clauses() {
+		if !c.match(wh) {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes the filter for display
+func (f webhookFilter) String() string {
+	var labels []string
+	for _, c := range f.clauses() {
+		labels = append(labels, c.label)
+	}
+	return strings.Join(labels, " ")
+}
+
+// query renders the filter back into the syntax accepted by parseFilter
+func (f webhookFilter) query() string {
+	var parts []string
+	if f.Path != "" {
+		parts = append(parts, "path:"+f.Path)
+	}
+	if f.Method != "" {
+		parts = append(parts, "method:"+f.Method)
+	}
+	if f.Since > 0 {
+		parts = append(parts, "since:"+f.Since.String())
+	}
+	if f.Body != "" {
+		parts = append(parts, "body:"+f.Body)
+	}
+	if f.MetaKey != "" {
+		meta := "meta:" + f.MetaKey
+		if f.MetaValue != "" {
+			meta += "=" + f.MetaValue
+		}
+		parts = append(parts, meta)
+	}
+	if f.JSONPath != "" {
+		field := "json:" + f.JSONPath
+		if f.JSONValue != "" {
+			field += "=" + f.JSONValue
+		}
+		parts = append(parts, field)
+	}
+	if f.RunID != "" {
+		parts = append(parts, "run:"+f.RunID)
+	}
+	if f.Regex != "" {
+		parts = append(parts, "re:"+f.Regex)
+	}
+	if f.HideProcessed {
+		parts = append(parts, "processed:no")
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseFilter parses filter input such as "path:/events method:post since:10m".
+// A bare word is treated as a path filter.
+func parseFilter(input string) (webhookFilter, error) {
+	var f webhookFilter
+	for _, token := range strings.Fields(input) {
+		key, value, found := strings.Cut(token, ":")
+		if !found {
+			f.Path = token
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "path":
+			f.Path = value
+		case "method":
+			f.Method = strings.ToUpper(value)
+		case "since":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return f, fmt.Errorf("invalid duration %q", value)
+			}
+			f.Since = d
+		case "body":
+			f.Body = value
+		case "meta":
+			f.MetaKey, f.MetaValue, _ = strings.Cut(value, "=")
+			if f.MetaKey == "" {
+				return f, fmt.Errorf("invalid meta filter %q (use meta:key or meta:key=value)", value)
+			}
+		case "json":
+			f.JSONPath, f.JSONValue, _ = strings.Cut(value, "=")
+			if err := validateJSONPath(f.JSONPath); err != nil {
+				return f, fmt.Errorf("invalid json filter %q: %v (use json:data.type or json:data.type=value)", value, err)
+			}
+		case "run":
+			f.RunID = value
+		case "re":
+			if _, err := regexp.Compile(value); err != nil {
+				return f, fmt.Errorf("invalid regex %q: %v", value, err)
+			}
+			f.Regex = value
+		case "processed":
+			switch strings.ToLower(value) {
+			case "no", "false":
+				f.HideProcessed = true
+			case "any", "all":
+				f.HideProcessed = false
+			default:
+				return f, fmt.Errorf("invalid processed filter %q (use processed:no)", value)
+			}
+		default:
+			return f, fmt.Errorf("unknown filter %q (use path:, method:, since:, body:, meta:, json:, run:, re: or processed:)", key)
+		}
+	}
+	return f, nil
+}
+
+// SQL functions for the filters: the REGEXP operator used by the re: filter,
+// which SQLite lacks, and json_path_text for the json: filter
+func init() {
+	var cache sync.Map // pattern -> *regexp.Regexp
+	sqlite.MustRegisterDeterministicScalarFunction("regexp", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		pattern, _ := args[0].(string)
+		var re *regexp.Regexp
+		if cached, ok := cache.Load(pattern); ok {
+			re = cached.(*regexp.Regexp)
+		} else {
+			var err error
+			if re, err = regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("invalid regex %q: %v", pattern, err)
+			}
+			cache.Store(pattern, re)
+		}
+		switch v := args[1].(type) {
+		case string:
+			return re.MatchString(v), nil
+		case []byte:
+			return re.Match(v), nil
+		}
+		return false, nil
+	})
+
+	// json_path_text(body_json, path) is jsonPathString, or NULL when the
+	// path is missing. json_extract reads numeric keys as array indexes and
+	// 1.50 as 1.5, so stored webhooks would match differently from live ones.
+	sqlite.MustRegisterDeterministicScalarFunction("json_path_text", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		var doc []byte
+		switch v := args[0].(type) {
+		case string:
+			doc = []byte(v)
+		case []byte:
+			doc = v
+		}
+		path, _ := args[1].(string)
+		body, err := decodeJSON(doc)
+		if err != nil {
+			return nil, nil
+		}
+		if _, err := lookupJSONPath(body, path); err != nil {
+			return nil, nil
+		}
+		return jsonPathString(body, path), nil
+	})
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func initDB() error {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return err
+	}
+
+	var err error
+	db, err = sql.Open("sqlite", dbPath)
+	if err != nil {
+		return err
+	}
+	// SQLite allows a single writer; funnel everything through one connection so
+	// concurrent handlers queue up instead of failing with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS webhooks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			method TEXT,
+			path TEXT,
+			headers TEXT,
+			body TEXT,
+			body_json TEXT
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// One row per tunnel URL assignment, to correlate webhooks with the URL they came through
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			started_at TEXT,
+			tunnel_url TEXT,
+			public_ip TEXT,
+			port TEXT
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Expected payload per path, for diffing later webhooks against it
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS baselines (
+			path TEXT PRIMARY KEY,
+			webhook_id INTEGER,
+			body TEXT,
+			created_at TEXT
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	return migrateDB()
+}
+
+// dbTimeLayout is how timestamps are stored: UTC with fixed-width nanoseconds,
+// so comparing them as text orders them correctly
+const dbTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// dbTime formats t for storage, and for comparing against stored timestamps
+func dbTime(t time.Time) string {
+	return t.UTC().Format(dbTimeLayout)
+}
+
+// storedTimeLayouts parse dbTimeLayout and what older versions stored:
+// RFC3339 in local time, and SQLite's CURRENT_TIMESTAMP (UTC)
+var storedTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+}
+
+// parseStoredTime reads a timestamp column value in local time
+func parseStoredTime(value string) (time.Time, bool) {
+	for _, layout := range storedTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Local(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// normalizeTimestamps rewrites timestamps stored in an older format with
+// dbTime, so text comparisons against cutoffs hold for every row
+func normalizeTimestamps(table, column string) error {
+	rows, err := db.Query(fmt.Sprintf("SELECT rowid, %[1]s FROM %[2]s WHERE length(%[1]s) != ? OR substr(%[1]s, -1) != 'Z'", column, table), len(dbTimeLayout))
+	if err != nil {
+		return err
+	}
+	updates := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var value string
+		if err := rows.Scan(&id, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		if t, ok := parseStoredTime(value); ok {
+			updates[id] = dbTime(t)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for id, value := range updates {
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE rowid = ?", table, column), value, id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// migrateDB adds columns introduced after the original schema, so existing
+// databases keep working. New columns must have a default for old rows.
+func migrateDB() error {
+	columns := []struct {
+		name       string
+		definition string
+	}{
+		{"delivery_id", "TEXT DEFAULT ''"},
+		{"parts", "TEXT DEFAULT ''"},
+		{"header_bytes", "INTEGER DEFAULT 0"},
+		{"processed", "INTEGER DEFAULT 0"},
+		{"response_status", "INTEGER DEFAULT 0"},
+		{"metadata", "TEXT DEFAULT ''"},
+		{"change_summary", "TEXT DEFAULT ''"},
+		{"response_rule", "TEXT DEFAULT ''"},
+		{"raw_uri", "TEXT DEFAULT ''"},
+		{"proto", "TEXT DEFAULT ''"},
+		{"run_id", "TEXT DEFAULT ''"},
+		{"sequence", "TEXT DEFAULT ''"},
+		{"sequence_expected", "INTEGER DEFAULT 0"},
+		{"headers_dropped", "INTEGER DEFAULT 0"},
+		{"port", "TEXT DEFAULT ''"},
+		{"is_xml", "INTEGER DEFAULT 0"},
+		{"header_lines", "TEXT DEFAULT ''"},
+		{"decompressed", "TEXT DEFAULT ''"},
+		{"signature_provider", "TEXT DEFAULT ''"},
+		{"signature_valid", "INTEGER"},
+		{"forward_status", "INTEGER DEFAULT 0"},
+		{"forward_error", "TEXT DEFAULT ''"},
+	}
+
+	existing := make(map[string]bool)
+	rows, err := db.Query("PRAGMA table_info(webhooks)")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, col := range columns {
+		if existing[col.name] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE webhooks ADD COLUMN %s %s", col.name, col.definition)); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", col.name, err)
+		}
+	}
+
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_webhooks_delivery_id ON webhooks(delivery_id)"); err != nil {
+		return err
+	}
+	if err := normalizeTimestamps("webhooks", "timestamp"); err != nil {
+		return fmt.Errorf("failed to normalize webhook timestamps: %w", err)
+	}
+	if err := normalizeTimestamps("sessions", "started_at"); err != nil {
+		return fmt.Errorf("failed to normalize session timestamps: %w", err)
+	}
+	if err := normalizeTimestamps("baselines", "created_at"); err != nil {
+		return fmt.Errorf("failed to normalize baseline timestamps: %w", err)
+	}
+	return nil
+}
+
+// saveWebhookToDB stores the webhook and returns its row id, which is the
+// canonical webhook id shown in the UI
+func saveWebhookToDB(payload WebhookPayload) (int, error) {
+	if db == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	headersJSON, _ := json.Marshal(payload.Headers)
+	var headerLinesJSON []byte
+	if len(payload.HeaderLines) > 0 {
+		headerLinesJSON, _ = json.Marshal(payload.HeaderLines)
+	}
+	bodyJSON := ""
+	if payload.BodyJSON != nil {
+		var b []byte
+		if config.PrettyBodyJSON {
+			b, _ = json.MarshalIndent(payload.BodyJSON, "", "  ")
+		} else {
+			b, _ = json.Marshal(payload.BodyJSON)
+		}
+		bodyJSON = string(b)
+	}
+	partsJSON := ""
+	if len(payload.Parts) > 0 {
+		b, _ := json.Marshal(payload.Parts)
+		partsJSON = string(b)
+	}
+
+	// Sub-second arrival times survive a reload and sort as text
+	res, err := db.Exec(`
+		INSERT INTO webhooks (timestamp, method, path, headers, body, body_json, delivery_id, parts, header_bytes, response_status, change_summary, response_rule, raw_uri, proto, run_id, sequence, sequence_expected, headers_dropped, port, is_xml, header_lines, decompressed, signature_provider, signature_valid)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, dbTime(payload.Timestamp), payload.Method, payload.Path, string(headersJSON), payload.Body, bodyJSON,
+		payload.DeliveryID, partsJSON, payload.HeaderBytes, payload.ResponseStatus, payload.ChangeSummary, payload.ResponseRule, payload.RawURI, payload.Proto, payload.RunID, payload.Sequence, payload.SequenceExpected, payload.HeadersDropped, payload.Port, payload.XML, string(headerLinesJSON), payload.Decompressed,
+		payload.SignatureProvider, payload.SignatureValid)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := evictOverflow(id); err != nil {
+		// The webhook is stored; the next insert evicts again
+		log.Printf("evicting rows over max_rows: %v", err)
+	}
+	return int(id), nil
+}
+
+// evictOverflow deletes the oldest rows beyond the configured cap. Ids only
+// grow, so everything at or below newest-cap goes; this stays an index range
+// delete however large the table is.
+func evictOverflow(newestID int64) error {
+	if config.MaxRows <= 0 {
+		return nil
+	}
+	_, err := db.Exec("DELETE FROM webhooks WHERE id <= ?", newestID-int64(config.MaxRows))
+	return err
+}
+
+// recordSession stores a tunnel URL assignment in the history
+func recordSession(tunnelURL, publicIP, port string) tea.Cmd {
+	startedAt := time.Now()
+	return func() tea.Msg {
+		if db == nil || config.DisableHistory {
+			return nil
+		}
+		if _, err := db.Exec(`INSERT INTO sessions (started_at, tunnel_url, public_ip, port) VALUES (?, ?, ?, ?)`,
+			dbTime(startedAt), tunnelURL, publicIP, port); err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to record session: %v", err))
+		}
+		return nil
+	}
+}
+
+// loadTunnelURLAt looks up the tunnel URL most recently assigned before
+// webhook id arrived at t
+func loadTunnelURLAt(id int, t time.Time) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return nil
+		}
+		var url string
+		db.QueryRow(`SELECT tunnel_url FROM sessions WHERE started_at <= ? ORDER BY started_at DESC, id DESC LIMIT 1`,
+			dbTime(t)).Scan(&url)
+		return detailTunnelURLMsg{id: id, url: url}
+	}
+}
+
+// baseline is the expected payload for a path
+type baseline struct {
+	webhookID int
+	body      string
+	bodyJSON  interface{}
+}
+
+func newBaseline(webhookID int, body string) baseline {
+	b := baseline{webhookID: webhookID, body: body}
+	b.bodyJSON, _ = decodeJSON([]byte(body))
+	return b
+}
+
+// loadBaselines loads the expected payloads keyed by path
+func loadBaselines() tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+
+		rows, err := db.Query("SELECT path, webhook_id, body FROM baselines")
+		if err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to load baselines: %v", err))
+		}
+		defer rows.Close()
+
+		baselines := make(map[string]baseline)
+		for rows.Next() {
+			var path, body string
+			var id int
+			if err := rows.Scan(&path, &id, &body); err != nil {
+				continue
+			}
+			baselines[path] = newBaseline(id, body)
+		}
+		return baselinesLoadedMsg(baselines)
+	}
+}
+
+// saveBaseline stores a copy of the webhook's body as the expected payload for
+// its path, so the baseline survives the webhook being deleted
+func saveBaseline(wh WebhookPayload) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+		_, err := db.Exec(`
+			INSERT INTO baselines (path, webhook_id, body, created_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(path) DO UPDATE SET webhook_id = excluded.webhook_id, body = excluded.body, created_at = excluded.created_at
+		`, wh.Path, wh.ID, wh.Body, dbTime(time.Now()))
+		if err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to save baseline: %v", err))
+		}
+		return nil
+	}
+}
+
+func deleteBaseline(path string) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+		if _, err := db.Exec("DELETE FROM baselines WHERE path = ?", path); err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to delete baseline: %v", err))
+		}
+		return nil
+	}
+}
+
+// diffBaseline lists how a webhook deviates from the expected payload, one
+// line per difference. JSON bodies are compared structurally.
+func diffBaseline(expected baseline, wh WebhookPayload) []string {
+	if expected.bodyJSON != nil && wh.BodyJSON != nil {
+		var diffs []string
+		diffJSON("", expected.bodyJSON, wh.BodyJSON, &diffs)
+		return diffs
+	}
+	if expected.body != wh.Body {
+		return []string{fmt.Sprintf("~ body differs (%s expected, %s received)", formatBytes(len(expected.body)), formatBytes(len(wh.Body)))}
+	}
+	return nil
+}
+
+func diffJSON(path string, expected, actual interface{}, diffs *[]string) {
+	label := path
+	if label == "" {
+		label = "(root)"
+	}
+
+	switch exp := expected.(type) {
+	case map[string]interface{}:
+		act, ok := actual.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(exp)+len(act))
+		for k := range exp {
+			keys = append(keys, k)
+		}
+		for k := range act {
+			if _, ok := exp[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			ev, inExp := exp[k]
+			av, inAct := act[k]
+			switch {
+			case !inAct:
+				*diffs = append(*diffs, "- "+child+" (missing)")
+			case !inExp:
+				*diffs = append(*diffs, "+ "+child+" (unexpected)")
+			default:
+				diffJSON(child, ev, av, diffs)
+			}
+		}
+		return
+
+	case []interface{}:
+		act, ok := actual.([]interface{})
+		if !ok {
+			break
+		}
+		if len(exp) != len(act) {
+			*diffs = append(*diffs, fmt.Sprintf("~ %s: %d items expected, %d received", label, len(exp), len(act)))
+		}
+		for i := 0; i < len(exp) && i < len(act); i++ {
+			diffJSON(fmt.Sprintf("%s[%d]", path, i), exp[i], act[i], diffs)
+		}
+		return
+	}
+
+	e, _ := json.Marshal(expected)
+	a, _ := json.Marshal(actual)
+	if !bytes.Equal(e, a) {
+		*diffs = append(*diffs, fmt.Sprintf("~ %s: %s → %s", label, truncate(string(e), 40), truncate(string(a), 40)))
+	}
+}
+
+// loadHistory loads recent tunnel URL assignments with the number of webhooks
+// received until the next assignment
+func loadHistory() tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+
+		rows, err := db.Query(`
+			SELECT s.started_at, s.tunnel_url, s.public_ip, s.port,
+				(SELECT COUNT(*) FROM webhooks w
+					WHERE w.timestamp >= s.started_at
+					AND w.timestamp < COALESCE(
+						(SELECT MIN(s2.started_at) FROM sessions s2 WHERE s2.started_at > s.started_at), '9999'))
+			FROM sessions s
+			ORDER BY s.id DESC
+			LIMIT 20
+		`)
+		if err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to load history: %v", err))
+		}
+		defer rows.Close()
+
+		var entries []historyEntry
+		for rows.Next() {
+			var e historyEntry
+			var startedAt string
+			if err := rows.Scan(&startedAt, &e.tunnelURL, &e.publicIP, &e.port, &e.webhooks); err != nil {
+				continue
+			}
+			e.startedAt, _ = parseStoredTime(startedAt)
+			entries = append(entries, e)
+		}
+		return historyLoadedMsg(entries)
+	}
+}
+
+// loadInfo gathers the database location, size and row stats for the info panel
+func loadInfo() tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+
+		info := &dbInfo{path: dbPath}
+		if fi, err := os.Stat(dbPath); err == nil {
+			info.fileSize = fi.Size()
+		}
+
+		var oldest, newest sql.NullString
+		err := db.QueryRow("SELECT COUNT(*), MIN(timestamp), MAX(timestamp) FROM webhooks").Scan(&info.rows, &oldest, &newest)
+		if err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to load info: %v", err))
+		}
+		info.oldest = oldest.String
+		info.newest = newest.String
+		if runID != "" {
+			db.QueryRow("SELECT COUNT(*) FROM webhooks WHERE run_id = ?", runID).Scan(&info.runRows)
+		}
+
+		return infoLoadedMsg(info)
+	}
+}
+
+// summarizeChange compares a new webhook with the latest stored one on the
+// same path. JSON bodies are diffed structurally like baselines.
+func summarizeChange(wh WebhookPayload) string {
+	if db == nil {
+		return ""
+	}
+	var prevBody string
+	err := db.QueryRow("SELECT body FROM webhooks WHERE path = ? ORDER BY id DESC LIMIT 1", wh.Path).Scan(&prevBody)
+	if err != nil {
+		return ""
+	}
+
+	prev := newBaseline(0, prevBody)
+	diffs := diffBaseline(prev, wh)
+	switch {
+	case len(diffs) == 0:
+		return "unchanged"
+	case prev.bodyJSON != nil && wh.BodyJSON != nil:
+		return fmt.Sprintf("changed (%d fields)", len(diffs))
+	default:
+		return "changed"
+	}
+}
+
+// countDeliveries returns how many stored webhooks already carry the delivery id
+func countDeliveries(deliveryID string) int {
+	if db == nil || deliveryID == "" {
+		return 0
+	}
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM webhooks WHERE delivery_id = ?", deliveryID).Scan(&count)
+	return count
+}
+
+// extractDeliveryID finds the provider delivery id from the configured (or a
+// well-known) header, or from the configured JSON field
+func extractDeliveryID(headers map[string]string, bodyJSON interface{}) string {
+	if config.DeliveryIDHeader != "" {
+		if id := headers[http.CanonicalHeaderKey(config.DeliveryIDHeader)]; id != "" {
+			return id
+		}
+	} else {
+		for _, h := range knownDeliveryIDHeaders {
+			if id := headers[http.CanonicalHeaderKey(h)]; id != "" {
+				return id
+			}
+		}
+	}
+
+	if config.DeliveryIDField != "" && bodyJSON != nil {
+		return jsonPathString(bodyJSON, config.DeliveryIDField)
+	}
+	return ""
+}
+
+// storedHeaders applies drop_headers / header_allowlist, returning the
+// headers to store and how many were left out
+func storedHeaders(headers map[string]string) (map[string]string, int) {
+	if config.DropHeaders {
+		return map[string]string{}, len(headers)
+	}
+	if len(config.HeaderAllowlist) == 0 {
+		return headers, 0
+	}
+	kept := make(map[string]string)
+	for k, v := range headers {
+		for _, allowed := range config.HeaderAllowlist {
+			if strings.EqualFold(k, allowed) {
+				kept[k] = v
+				break
+			}
+		}
+	}
+	return kept, len(headers) - len(kept)
+}
+
+// extractSequence reads the configured sequence number header or JSON field
+func extractSequence(headers map[string]string, bodyJSON interface{}) string {
+	if config.SequenceHeader != "" {
+		if seq := headers[http.CanonicalHeaderKey(config.SequenceHeader)]; seq != "" {
+			return seq
+		}
+	}
+	if config.SequenceField != "" && bodyJSON != nil {
+		return jsonPathString(bodyJSON, config.SequenceField)
+	}
+	return ""
+}
+
+// expectedSequence is one past the highest sequence number stored for the
+// path, or 0 when there is none to compare with
+func expectedSequence(path string) int64 {
+	if db == nil {
+		return 0
+	}
+	var highest sql.NullInt64
+	db.QueryRow("SELECT MAX(CAST(sequence AS INTEGER)) FROM webhooks WHERE path = ? AND sequence != ''", path).Scan(&highest)
+	if !highest.Valid {
+		return 0
+	}
+	return highest.Int64 + 1
+}
+
+// sequenceProblem describes how a webhook's sequence number deviates from
+// the expected one, or "" when it is in order (or not tracked)
+func (wh WebhookPayload) sequenceProblem() string {
+	if wh.Sequence == "" || wh.SequenceExpected == 0 {
+		return ""
+	}
+	seq, err := strconv.ParseInt(wh.Sequence, 10, 64)
+	if err != nil {
+		return "not a number"
+	}
+	switch {
+	case seq == wh.SequenceExpected:
+		return ""
+	case seq > wh.SequenceExpected:
+		return fmt.Sprintf("gap: %d missing", seq-wh.SequenceExpected)
+	case seq == wh.SequenceExpected-1:
+		return "repeated"
+	default:
+		return "out of order"
+	}
+}
+
+// webhookColumns is the column list read by scanWebhook. The retry count is
+// the number of earlier rows sharing the same delivery id.
+const webhookColumns = `id, timestamp, method, path, headers, body, body_json, delivery_id, parts, header_bytes, processed, response_status, metadata, change_summary, response_rule, raw_uri, proto, run_id, sequence, sequence_expected, headers_dropped, port, is_xml, header_lines, decompressed, signature_provider, signature_valid, forward_status, forward_error,
+	(SELECT COUNT(*) FROM webhooks w2
+		WHERE webhooks.delivery_id != '' AND w2.delivery_id = webhooks.delivery_id AND w2.id < webhooks.id)`
+
+// scanWebhook reads one row selected with webhookColumns
+func scanWebhook(rows *sql.Rows) (WebhookPayload, error) {
+	var w WebhookPayload
+	var headersJSON, bodyJSON, partsJSON, metadataJSON, headerLinesJSON string
+	var timestamp string
+
+	err := rows.Scan(&w.ID, &timestamp, &w.Method, &w.Path, &headersJSON, &w.Body, &bodyJSON,
+		&w.DeliveryID, &partsJSON, &w.HeaderBytes, &w.Processed, &w.ResponseStatus, &metadataJSON, &w.ChangeSummary, &w.ResponseRule, &w.RawURI, &w.Proto, &w.RunID, &w.Sequence, &w.SequenceExpected, &w.HeadersDropped, &w.Port, &w.XML, &headerLinesJSON, &w.Decompressed, &w.SignatureProvider, &w.SignatureValid, &w.ForwardStatus, &w.ForwardError, &w.RetryNum)
+	if err != nil {
+		return w, err
+	}
+
+	w.Timestamp, _ = parseStoredTime(timestamp)
+	json.Unmarshal([]byte(headersJSON), &w.Headers)
+	if bodyJSON != "" {
+		w.BodyJSON, _ = decodeJSON([]byte(bodyJSON))
+	}
+	if partsJSON != "" {
+		json.Unmarshal([]byte(partsJSON), &w.Parts)
+	}
+	if metadataJSON != "" {
+		json.Unmarshal([]byte(metadataJSON), &w.Metadata)
+	}
+	if headerLinesJSON != "" {
+		json.Unmarshal([]byte(headerLinesJSON), &w.HeaderLines)
+	}
+
+	return w, nil
+}
+
+// decodeJSON parses a JSON document keeping numbers as json.Number, so large
+// integers and exact decimals re-serialize unchanged after a store/load round trip
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+// loadWebhookByID loads a single stored webhook
+func loadWebhookByID(id int) (WebhookPayload, error) {
+	if db == nil {
+		return WebhookPayload{}, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := db.Query("SELECT "+webhookColumns+" FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return WebhookPayload{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return WebhookPayload{}, err
+		}
+		return WebhookPayload{}, fmt.Errorf("webhook #%d not found", id)
+	}
+	return scanWebhook(rows)
+}
+
+// updateMetadata sets metadata keys on a stored webhook; an empty value removes
+// the key. Returns the resulting metadata.
+func updateMetadata(id int, set map[string]string) (map[string]string, error) {
+	wh, err := loadWebhookByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := wh.Metadata
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	for k, v := range set {
+		if v == "" {
+			delete(metadata, k)
+		} else {
+			metadata[k] = v
+		}
+	}
+
+	stored := ""
+	if len(metadata) > 0 {
+		b, _ := json.Marshal(metadata)
+		stored = string(b)
+	}
+	if _, err := db.Exec("UPDATE webhooks SET metadata = ? WHERE id = ?", stored, id); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// parseMultipart summarizes a multipart/form-data body. Returns nil if the
+// content type isn't multipart or the body can't be parsed.
+func parseMultipart(contentType string, body []byte) []MultipartPart {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil
+	}
+
+	var parts []MultipartPart
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		p, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+
+		data, _ := io.ReadAll(p)
+		part := MultipartPart{
+			Name:        p.FormName(),
+			Filename:    p.FileName(),
+			ContentType: p.Header.Get("Content-Type"),
+			Size:        len(data),
+		}
+		// Keep text field values, but only sizes for file contents
+		if part.Filename == "" {
+			part.Value = string(data)
+			if len(part.Value) > maxPartValue {
+				part.Value = part.Value[:maxPartValue]
+			}
+		}
+		parts = append(parts, part)
+		p.Close()
+	}
+
+	return parts
+}
+
+// countWebhooks counts stored webhooks matching the filter
+func countWebhooks(filter webhookFilter) (int, error) {
+	if db == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	where, args := filter.whereClause()
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM webhooks"+where, args...).Scan(&count)
+	return count, err
+}
+
+// queryWebhooks loads webhooks matching the filter, newest first
+func queryWebhooks(filter webhookFilter, limit, offset int) ([]WebhookPayload, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	where, args := filter.whereClause()
+	rows, err := db.Query(`
+		SELECT `+webhookColumns+`
+		FROM webhooks`+where+`
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []WebhookPayload
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			continue
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+func loadWebhooksFromDB(page, size int, filter webhookFilter) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+
+		// Get total count
+		totalCount, err := countWebhooks(filter)
+		if err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to count webhooks: %v", err))
+		}
+
+		// A smaller result set or a larger page size can leave page past the end
+		page = max(0, min(page, (totalCount-1)/size))
+		webhooks, err := queryWebhooks(filter, size, page*size)
+		if err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to load webhooks: %v", err))
+		}
+
+		return webhooksLoadedMsg{
+			webhooks:    webhooks,
+			totalCount:  totalCount,
+			currentPage: page,
+		}
+	}
+}
+
+// forEachWebhook streams every stored webhook matching the filter, oldest
+// first, without loading the whole result set into memory
+func forEachWebhook(filter webhookFilter, fn func(WebhookPayload) error) error {
+	if db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	where, args := filter.whereClause()
+	rows, err := db.Query(`
+		SELECT `+webhookColumns+`
+		FROM webhooks`+where+`
+		ORDER BY id ASC
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(w); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// loadStats computes aggregate stats for webhooks matching the filter
+func loadStats(filter webhookFilter, metricIdx int) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+
+		metric := histogramMetrics[metricIdx]
+		stats := &statsData{metric: metric.name}
+
+		// Bucket in SQL: bucket i holds values below histogramBounds[i],
+		// the last bucket everything above
+		var caseExpr strings.Builder
+		caseExpr.WriteString("CASE")
+		for i, bound := range histogramBounds {
+			caseExpr.WriteString(fmt.Sprintf(" WHEN %s < %d THEN %d", metric.expr, bound, i))
+		}
+		caseExpr.WriteString(fmt.Sprintf(" ELSE %d END", len(histogramBounds)))
+
+		where, args := filter.whereClause()
+		rows, err := db.Query("SELECT "+caseExpr.String()+" AS bucket, COUNT(*) FROM webhooks"+where+" GROUP BY bucket", args...)
+		if err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to load stats: %v", err))
+		}
+		defer rows.Close()
+
+		counts := make(map[int]int)
+		for rows.Next() {
+			var bucket, count int
+			if err := rows.Scan(&bucket, &count); err == nil {
+				counts[bucket] = count
+			}
+		}
+
+		for i := 0; i <= len(histogramBounds); i++ {
+			var label string
+			switch {
+			case i == 0:
+				label = "empty"
+			case i == len(histogramBounds):
+				label = fmt.Sprintf(">= %s", formatBytes(histogramBounds[i-1]))
+			default:
+				label = fmt.Sprintf("< %s", formatBytes(histogramBounds[i]))
+			}
+			stats.histogram = append(stats.histogram, histogramBucket{label: label, count: counts[i]})
+		}
+
+		if err := loadStatsSummary(stats, where, args); err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to load stats: %v", err))
+		}
+		return statsLoadedMsg(stats)
+	}
+}
+
+// loadStatsSummary fills in the totals and per-method and per-status counts
+// for the webhooks matching the where clause
+func loadStatsSummary(stats *statsData, where string, args []interface{}) error {
+	err := db.QueryRow("SELECT COUNT(*), COALESCE(MIN(timestamp), ''), COALESCE(MAX(timestamp), '') FROM webhooks"+where, args...).
+		Scan(&stats.total, &stats.earliest, &stats.latest)
+	if err != nil {
+		return err
+	}
+
+	sessionWhere := " WHERE timestamp >= ?"
+	if where != "" {
+		sessionWhere = where + " AND timestamp >= ?"
+	}
+	sessionArgs := append(append([]interface{}{}, args...), dbTime(sessionStart))
+	if err := db.QueryRow("SELECT COUNT(*) FROM webhooks"+sessionWhere, sessionArgs...).Scan(&stats.session); err != nil {
+		return err
+	}
+
+	groups := []struct {
+		expr string
+		into *[]histogramBucket
+	}{
+		{"method", &stats.methods},
+		{"CAST(response_status AS TEXT)", &stats.statuses},
+	}
+	for _, g := range groups {
+		rows, err := db.Query("SELECT "+g.expr+" AS k, COUNT(*) AS n FROM webhooks"+where+" GROUP BY k ORDER BY n DESC, k", args...)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var bucket histogramBucket
+			if err := rows.Scan(&bucket.label, &bucket.count); err == nil {
+				*g.into = append(*g.into, bucket)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadCleanup counts rows and bytes per age bucket. Size is the sum of the
+// stats metrics so both screens agree on what a webhook weighs.
+func loadCleanup() tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+
+		exprs := make([]string, len(histogramMetrics))
+		for i, metric := range histogramMetrics {
+			exprs[i] = metric.expr
+		}
+		sizeExpr := strings.Join(exprs, " + ")
+
+		buckets := cleanupBuckets(time.Now())
+		for i := range buckets {
+			where, args := buckets[i].whereClause()
+			err := db.QueryRow("SELECT COUNT(*), COALESCE(SUM("+sizeExpr+"), 0) FROM webhooks"+where, args...).
+				Scan(&buckets[i].count, &buckets[i].size)
+			if err != nil {
+				return dbErrorMsg(fmt.Sprintf("Failed to load cleanup stats: %v", err))
+			}
+		}
+		return cleanupLoadedMsg(buckets)
+	}
+}
+
+// databaseBytes is the allocated size of the database in pages
+func databaseBytes() int64 {
+	var pages, pageSize int64
+	db.QueryRow("PRAGMA page_count").Scan(&pages)
+	db.QueryRow("PRAGMA page_size").Scan(&pageSize)
+	return pages * pageSize
+}
+
+// deleteCleanupBucket deletes a bucket's rows and vacuums to give the space back
+func deleteCleanupBucket(bucket cleanupBucket) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+
+		before := databaseBytes()
+		where, args := bucket.whereClause()
+		res, err := db.Exec("DELETE FROM webhooks"+where, args...)
+		if err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to delete webhooks: %v", err))
+		}
+		count, _ := res.RowsAffected()
+		if count == 0 {
+			// Nothing freed; skip rewriting the whole file
+			return cleanupDoneMsg{label: bucket.label}
+		}
+		if _, err := db.Exec("VACUUM"); err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to vacuum database: %v", err))
+		}
+
+		return cleanupDoneMsg{label: bucket.label, count: count, reclaimed: before - databaseBytes()}
+	}
+}
+
+// purgeOnStartup deletes the webhooks retain_days says to drop when the app
+// starts, reporting them apart from a cleanup the user asked for
+func purgeOnStartup(days int) tea.Cmd {
+	purge := deleteCleanupBucket(retentionBucket(days, time.Now()))
+	return func() tea.Msg {
+		msg := purge()
+		if done, ok := msg.(cleanupDoneMsg); ok {
+			return startupPurgedMsg(done)
+		}
+		return msg
+	}
+}
+
+// sentRequest is wh with the headers and body exactly as received, before
+// privacy filtering, truncation and decompression, so signatures still verify
+func sentRequest(wh WebhookPayload, header http.Header, body []byte) WebhookPayload {
+	wh.Body, wh.Decompressed = string(body), ""
+	wh.Headers = make(map[string]string, len(header))
+	wh.HeaderLines = make(map[string][]string, len(header))
+	for k, v := range header {
+		wh.Headers[k] = strings.Join(v, ", ")
+		wh.HeaderLines[k] = v
+	}
+	return wh
+}
+
+// forwardWebhook mirrors a stored webhook to forward_to, records the outcome
+// and reports it to the UI. Failures never affect capturing.
+func forwardWebhook(wh WebhookPayload, target string, results chan<- forwardResultMsg) {
+	msg := forwardResultMsg{id: wh.ID}
+	if result, err := replayWebhook(wh, target); err != nil {
+		msg.err = err.Error()
+	} else {
+		msg.status = result.code
+	}
+	if db != nil {
+		if _, err := db.Exec("UPDATE webhooks SET forward_status = ?, forward_error = ? WHERE id = ?", msg.status, msg.err, wh.ID); err != nil {
+			log.Printf("recording forward of #%d: %v", wh.ID, err)
+		}
+	}
+	select {
+	case results <- msg:
+	default:
+		// The UI is behind; the result is in the database
+	}
+}
+
+// waitForForward delivers forwarding results to the UI
+func waitForForward(ch chan forwardResultMsg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+// waitForAlert delivers alert rule matches to the UI
+func waitForAlert(ch chan alertMsg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+// flashDuration is how long a flashing alert stays in the title bar
+const flashDuration = 2 * time.Second
+
+func scheduleFlashEnd() tea.Cmd {
+	return tea.Tick(flashDuration, func(time.Time) tea.Msg {
+		return flashEndMsg{}
+	})
+}
+
+// bellDuration is how long View keeps BEL in the frame; long enough for the
+// renderer to write one frame with it
+const bellDuration = 100 * time.Millisecond
+
+// ringBell puts BEL in the next frames. tea.Printf output is dropped in the
+// alternate screen, and writing to the terminal outside the renderer could
+// split an escape sequence it is writing.
+func (m *Model) ringBell() tea.Cmd {
+	m.bell = true
+	return tea.Tick(bellDuration, func(time.Time) tea.Msg {
+		return bellEndMsg{}
+	})
+}
+
+// setProcessed persists the processed flag of a webhook
+func setProcessed(id int, processed bool) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+		if _, err := db.Exec("UPDATE webhooks SET processed = ? WHERE id = ?", processed, id); err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to update webhook: %v", err))
+		}
+		return nil
+	}
+}
+
+// deleteWebhook deletes a single stored webhook
+func deleteWebhook(id int) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+		if _, err := db.Exec("DELETE FROM webhooks WHERE id = ?", id); err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to delete webhook: %v", err))
+		}
+		return webhookDeletedMsg{id: id}
+	}
+}
+
+// deleteFilteredWebhooks deletes every stored webhook matching the filter
+func deleteFilteredWebhooks(filter webhookFilter) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+
+		where, args := filter.whereClause()
+		res, err := db.Exec("DELETE FROM webhooks"+where, args...)
+		if err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to delete webhooks: %v", err))
+		}
+		count, _ := res.RowsAffected()
+		return webhooksDeletedMsg{count: count}
+	}
+}
+
+// The setup screen's fields: port, subdomain, timeout and the provider selector
+const (
+	setupFields        = 4
+	setupProviderField = 3
+)
+
+// Setup input widths: the preferred width on a roomy terminal and the
+// narrowest they shrink to
+const (
+	portInputWidth      = 20
+	subdomainInputWidth = 30
+	timeoutInputWidth   = 10
+	minSetupInputWidth  = 6
+)
+
+// layoutSetupInputs fits the setup inputs to the terminal width, leaving room
+// for the prompt and cursor
+func (m *Model) layoutSetupInputs(width int) {
+	avail := width - 4
+	fit := func(preferred int) int {
+		return max(minSetupInputWidth, min(preferred, avail))
+	}
+	m.portInput.Width = fit(portInputWidth)
+	m.subdomainInput.Width = fit(subdomainInputWidth)
+	m.timeoutInput.Width = fit(timeoutInputWidth)
+}
+
+func initialModel() Model {
+	portInput := textinput.New()
+	portInput.Placeholder = "8098"
+	portInput.Focus()
+	portInput.CharLimit = 60 // a comma-separated list of ports
+	portInput.Width = portInputWidth
+
+	subdomainInput := textinput.New()
+	subdomainInput.Placeholder = "my-webhook-listener"
+	subdomainInput.CharLimit = 50
+	subdomainInput.Width = subdomainInputWidth
+
+	timeoutInput := textinput.New()
+	timeoutInput.Placeholder = "30m"
+	timeoutInput.CharLimit = 10
+	timeoutInput.Width = timeoutInputWidth
+
+	s := spinner.New()
+	s.Spinner = config.spinnerStyle()
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	searchInput := textinput.New()
+	searchInput.Placeholder = ""
+	searchInput.CharLimit = 100
+	searchInput.Width = 30
+	searchInput.Prompt = searchPrompt(false)
+
+	filterInput := textinput.New()
+	filterInput.Placeholder = "path or path: method: since: body:"
+	filterInput.CharLimit = 100
+	filterInput.Width = 40
+	filterInput.Prompt = "filter: "
+
+	jqInput := textinput.New()
+	jqInput.Placeholder = ".data.object"
+	jqInput.CharLimit = 200
+	jqInput.Width = 50
+	jqInput.Prompt = "jq "
+
+	pathInput := textinput.New()
+	pathInput.Placeholder = ".data.items[0].id"
+	pathInput.CharLimit = 200
+	pathInput.Width = 50
+	pathInput.Prompt = ": "
+
+	replayInput := textinput.New()
+	replayInput.Placeholder = "http://localhost:3000"
+	replayInput.CharLimit = 200
+	replayInput.Width = 50
+	replayInput.Prompt = "replay to: "
+
+	// Prefill from the defaults saved last time
+	if config.DefaultPort != "" {
+		portInput.SetValue(config.DefaultPort)
+	}
+	if config.DefaultSubdomain != "" {
+		subdomainInput.SetValue(config.DefaultSubdomain)
+	}
+	if config.DefaultTimeoutMinutes > 0 {
+		timeoutInput.SetValue(strconv.Itoa(config.DefaultTimeoutMinutes))
+	}
+	providerIdx := 0
+	for i, p := range tunnelProviders {
+		if strings.EqualFold(p.Name(), config.DefaultProvider) {
+			providerIdx = i
+		}
+	}
+
+	paletteInput := textinput.New()
+	paletteInput.Placeholder = "type to filter actions"
+	paletteInput.CharLimit = 50
+	paletteInput.Width = 40
+
+	return Model{
+		state:          StateSetup,
+		portInput:      portInput,
+		subdomainInput: subdomainInput,
+		timeoutInput:   timeoutInput,
+		focusedInput:   0,
+		providerIdx:    providerIdx,
+		spinner:        s,
+		fetchingIP:     true,
+		spinning:       true, // started by Init
+		webhooks:       make([]WebhookPayload, 0),
+		webhooksMu:     &sync.Mutex{},
+		overrides:      newResponseOverrides(),
+		discarded:      new(atomic.Int64),
+		droppedLive:    new(atomic.Int64),
+		captured:       new(atomic.Int64),
+		notFound:       new(atomic.Int64),
+		forwardPaused:  new(atomic.Bool),
+		forwardChan:    make(chan forwardResultMsg, 100),
+		alertChan:      make(chan alertMsg, 100),
+		webhookChan:    make(chan WebhookPayload, 100),
+		viewMode:       config.viewMode(),
+		currentPage:    0,
+		pageSize:       config.pageSize(),
+		tunnelTimeout:  defaultTunnelTimeout,
+		searchInput:    searchInput,
+		filterInput:    filterInput,
+		jqInput:        jqInput,
+		pathInput:      pathInput,
+		replayInput:    replayInput,
+		paletteInput:   paletteInput,
+		autoOpen:       config.AutoOpenDetail,
+		notify:         config.Notifications,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	cmds := []tea.Cmd{
+		textinput.Blink,
+		m.spinner.Tick,
+		fetchPublicIP,
+		loadWebhooksFromDB(0, config.pageSize(), webhookFilter{}), // Load previous webhooks on startup
+		loadBaselines(),
+	}
+	if config.RetainDays > 0 {
+		cmds = append(cmds, purgeOnStartup(config.RetainDays))
+	}
+	return tea.Batch(cmds...)
+}
+
+// Commands
+func fetchPublicIP() tea.Msg {
+	resp, err := http.Get("https://api.ipify.org")
+	if err != nil {
+		// Try backup service
+		resp, err = http.Get("https://ifconfig.me/ip")
+		if err != nil {
+			return publicIPErrMsg(err)
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return publicIPErrMsg(err)
+	}
+
+	return publicIPMsg(strings.TrimSpace(string(body)))
+}
+
+// TunnelProvider exposes the local port publicly. Start returns the running
+// process (killed with its process group on shutdown), a channel that
+// receives its exit status (see watchProcess) and the public URL.
+type TunnelProvider interface {
+	Name() string
+	Start(port, subdomain string) (*exec.Cmd, <-chan error, string, error)
+}
+
+// watchProcess waits on a started process in the background. Wait may only
+// be called once, so everything interested in the exit reads this channel.
+func watchProcess(cmd *exec.Cmd) <-chan error {
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+		close(exited)
+	}()
+	return exited
+}
+
+// tunnelProviders are offered on the setup screen; the first is the default
+var tunnelProviders = []TunnelProvider{LocalTunnelProvider{}, NgrokProvider{}}
+
+// LocalTunnelProvider runs localtunnel through npx
+type LocalTunnelProvider struct{}
+
+func (LocalTunnelProvider) Name() string { return "localtunnel" }
+
+func (LocalTunnelProvider) Start(port, subdomain string) (*exec.Cmd, <-chan error, string, error) {
+	args := []string{"localtunnel", "--port", port}
+	if subdomain != "" {
+		args = append(args, "--subdomain", subdomain)
+	}
+
+	cmd := exec.Command("npx", args...)
+	// Set process group so we can kill all children on exit
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("Failed to create stdout pipe: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, "", fmt.Errorf("Failed to start localtunnel: %v", err)
+	}
+
+	// Read the URL from stdout
+	buf := make([]byte, 1024)
+	n, err := stdout.Read(buf)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("Failed to read tunnel URL: %v", err)
+	}
+
+	output := string(buf[:n])
+	// Parse out the URL from localtunnel output
+	// Output typically looks like: "your url is: https://xxx.loca.lt"
+	url := output
+	if idx := strings.Index(output, "https://"); idx != -1 {
+		url = strings.TrimSpace(output[idx:])
+		if newline := strings.Index(url, "\n"); newline != -1 {
+			url = url[:newline]
+		}
+	}
+	return cmd, watchProcess(cmd), url, nil
+}
+
+// NgrokProvider runs an already authenticated ngrok agent. The subdomain
+// field takes a reserved domain (e.g. my-app.ngrok.app).
+type NgrokProvider struct{}
+
+func (NgrokProvider) Name() string { return "ngrok" }
+
+// Start reads the public URL from the agent's own JSON log. The local API on
+// :4040 may belong to another ngrok agent already running on this machine.
+func (NgrokProvider) Start(port, subdomain string) (*exec.Cmd, <-chan error, string, error) {
+	args := []string{"http", port, "--log", "stderr", "--log-format", "json"}
+	if subdomain != "" {
+		args = append(args, "--domain", subdomain)
+	}
+
+	cmd := exec.Command("ngrok", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	stderr := &syncBuffer{}
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, nil, "", fmt.Errorf("Failed to start ngrok: %v", err)
+	}
+	exited := watchProcess(cmd)
+
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case <-exited:
+			return nil, nil, "", fmt.Errorf("ngrok exited: %s", ngrokLastError(stderr.String()))
+		case <-time.After(250 * time.Millisecond):
+		}
+		if url := ngrokTunnelURL(stderr.String(), port); url != "" {
+			return cmd, exited, url, nil
+		}
+	}
+
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	return nil, nil, "", fmt.Errorf("ngrok did not report a tunnel URL for port %s", port)
+}
+
+// ngrokLogLine is the part of an ngrok JSON log line we use
+type ngrokLogLine struct {
+	Msg  string `json:"msg"`
+	Err  string `json:"err"`
+	Addr string `json:"addr"`
+	URL  string `json:"url"`
+}
+
+// ngrokTunnelURL finds the https tunnel started for the local port in the
+// agent's log, or "" while there is none yet
+func ngrokTunnelURL(output, port string) string {
+	for _, line := range strings.Split(output, "\n") {
+		var entry ngrokLogLine
+		if json.Unmarshal([]byte(line), &entry) != nil || entry.Msg != "started tunnel" || !strings.HasPrefix(entry.URL, "https://") {
+			continue
+		}
+		// addr is e.g. "http://localhost:8098"
+		if u, err := url.Parse(entry.Addr); err == nil && u.Port() == port {
+			return entry.URL
+		}
+	}
+	return ""
+}
+
+// ngrokLastError picks the reason from the last log line of an exited agent;
+// fatal errors are printed as plain text rather than JSON
+func ngrokLastError(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	var entry ngrokLogLine
+	if json.Unmarshal([]byte(last), &entry) == nil {
+		if entry.Err != "" {
+			return entry.Err
+		}
+		return entry.Msg
+	}
+	return last
+}
+
+// syncBuffer is a bytes.Buffer that can be read while a process writes to it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func startTunnel(provider TunnelProvider, port, subdomain string) tea.Cmd {
+	return func() tea.Msg {
+		cmd, exited, url, err := provider.Start(port, subdomain)
+		if err != nil {
+			return tunnelErrorMsg(err.Error())
+		}
+		return tunnelStartedMsg{url: url, cmd: cmd, exited: exited}
+	}
+}
+
+// waitTunnelExit reports when the tunnel process ends, however that happens
+func waitTunnelExit(cmd *exec.Cmd, exited <-chan error) tea.Cmd {
+	return func() tea.Msg {
+		return tunnelDiedMsg{cmd: cmd, err: <-exited}
+	}
+}
+
+// webhookMux builds the capture handler around the model's shared state.
+// A fresh mux per start; the default one would panic on a second registration.
+func (m *Model) webhookMux() *http.ServeMux {
+	webhookChan := m.webhookChan
+	overrides := m.overrides
+	discarded := m.discarded
+	droppedLive := m.droppedLive
+	captured := m.captured
+	notFound := m.notFound
+	forwardPaused := m.forwardPaused
+	forwardChan := m.forwardChan
+	alertChan := m.alertChan
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// Readiness probe sent through the tunnel - echo the token, don't capture
+		if r.URL.Path == readinessPath {
+			w.Write([]byte(r.URL.Query().Get("token")))
+			return
+		}
+
+		// Strict mode: anything but the expected path is a 404
+		if config.StrictPath != "" {
+			if r.URL.Path != config.StrictPath {
+				io.Copy(io.Discard, r.Body)
+				notFound.Add(1)
+				http.NotFound(w, r)
+				return
+			}
+			captured.Add(1)
+		}
+
+		// Acknowledge but don't store requests outside the capture filter
+		if !config.Capture.matches(r) {
+			io.Copy(io.Discard, r.Body)
+			discarded.Add(1)
+			responseFor(r.Method).write(w)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Failed to read body", http.StatusBadRequest)
+			return
+		}
+		defer r.Body.Close()
+
+		// Signatures cover the body exactly as it was sent
+		signatureProvider, signatureValid := verifySignature(r.Header, body)
+
+		// Store compressed bodies decoded so they can be parsed and read
+		sent, encoding := body, ""
+		if inflated, enc, err := decompressBody(r.Header.Get("Content-Encoding"), body); err == nil {
+			body, encoding = inflated, enc
+		}
+
+		headers := make(map[string]string)
+		headerLines := make(map[string][]string)
+		headerBytes := 0
+		for k, v := range r.Header {
+			value := strings.Join(v, ", ")
+			headerBytes += len(k) + len(value) + 4 // ": " and CRLF
+			// Keep storing oversized headers, but only up to a cap
+			if len(value) > maxStoredHeaderValue {
+				value = cutAtRune(value, maxStoredHeaderValue) + "…(truncated)"
+			} else if len(v) > 1 {
+				headerLines[k] = v
+			}
+			headers[k] = value
+		}
+
+		payload := WebhookPayload{
+			Timestamp: time.Now(),
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Headers:   headers,
+			Body:      string(body),
+			RawURI:    r.URL.RequestURI(),
+			Proto:     r.Proto,
+			RunID:     runID,
+			Port:      localPort(r),
+		}
+		payload.Decompressed = encoding
+		payload.SignatureProvider, payload.SignatureValid = signatureProvider, signatureValid
+		payload.HeaderBytes = headerBytes
+		if len(headerLines) > 0 {
+			payload.HeaderLines = headerLines
+		}
+
+		// Try to parse body as JSON for pretty display
+		if jsonBody, err := decodeJSON(body); err == nil && !payload.forcedText() {
+			payload.BodyJSON = jsonBody
+		} else if override := contentTypeOverride(payload.Path); override == "" || strings.Contains(override, "xml") {
+			payload.XML = looksLikeXML(body)
+		}
+
+		// Summarize multipart bodies and avoid storing huge file contents
+		contentType := r.Header.Get("Content-Type")
+		if override := contentTypeOverride(payload.Path); override != "" {
+			contentType = override
+		}
+		if parts := parseMultipart(contentType, body); parts != nil {
+			payload.Parts = parts
+			if len(payload.Body) > maxStoredMultipartBody {
+				payload.Body = cutAtRune(payload.Body, maxStoredMultipartBody)
+			}
+		}
+
+		// Flag provider retries of the same logical event
+		payload.DeliveryID = extractDeliveryID(headers, payload.BodyJSON)
+		payload.RetryNum = countDeliveries(payload.DeliveryID)
+		payload.ChangeSummary = summarizeChange(payload)
+		if payload.Sequence = extractSequence(headers, payload.BodyJSON); payload.Sequence != "" {
+			payload.SequenceExpected = expectedSequence(payload.Path)
+		}
+
+		// A path override wins over the configured rule/method/default response
+		response, rule := responseForWebhook(payload)
+		payload.ResponseRule = rule
+		if status, ok := overrides.get(payload.Path); ok {
+			payload.ResponseRule = ""
+			response = ResponseConfig{Status: status, Body: http.StatusText(status) + "\n", ContentType: "text/plain; charset=utf-8"}
+		}
+		payload.ResponseStatus = response.Status
+
+		// Privacy settings apply to what is stored, after everything derived from headers
+		payload.Headers, payload.HeadersDropped = storedHeaders(payload.Headers)
+		for k := range payload.HeaderLines {
+			if _, kept := payload.Headers[k]; !kept {
+				delete(payload.HeaderLines, k)
+			}
+		}
+
+		// Save to database first - the row id is the webhook's id and defines arrival order
+		id, err := saveWebhookToDB(payload)
+		if err != nil {
+			http.Error(w, "Failed to store webhook", http.StatusInternalServerError)
+			return
+		}
+		payload.ID = id
+
+		select {
+		case webhookChan <- payload:
+		default:
+			// Channel full, drop from live view (still stored in DB)
+			n := droppedLive.Add(1)
+			log.Printf("live view full, dropped webhook #%d %s %s (%d dropped so far)", payload.ID, payload.Method, payload.Path, n)
+		}
+
+		// Alerts go on their own channel so a full live view can't drop them
+		if rule, ok := alertFor(payload); ok {
+			select {
+			case alertChan <- alertMsg{rule: rule, id: payload.ID, title: payload.Method + " " + payload.Path}:
+			default:
+			}
+		}
+
+		// Mirror to the dev server without holding up the sender's response
+		if config.ForwardTo != "" && !forwardPaused.Load() {
+			go forwardWebhook(sentRequest(payload, r.Header, sent), config.ForwardTo, forwardChan)
+		}
+
+		response.write(w)
+	})
+	return mux
+}
+
+func (m *Model) startWebhookServer() tea.Cmd {
+	return func() tea.Msg {
+		ports := m.ports
+		mux := m.webhookMux()
+
+		// Bind synchronously so a busy port is reported instead of silently ignored
+		var listeners []net.Listener
+		if config.ListenSocket != "" {
+			// A socket file left by a previous run would make the bind fail
+			removeSocket(config.ListenSocket)
+			ln, err := net.Listen("unix", config.ListenSocket)
+			if err != nil {
+				return serverErrorMsg(fmt.Sprintf("Failed to listen on %s: %v", config.ListenSocket, err))
+			}
+			listeners = append(listeners, ln)
+		} else {
+			for _, port := range ports {
+				ln, err := net.Listen("tcp", ":"+port)
+				if err != nil {
+					for _, bound := range listeners {
+						bound.Close()
+					}
+					return serverErrorMsg(fmt.Sprintf("Failed to listen on port %s: %v", port, err))
+				}
+				listeners = append(listeners, ln)
+			}
+		}
+
+		// One server per listener, all feeding the same handler and channel
+		var servers []*http.Server
+		for _, ln := range listeners {
+			srv := &http.Server{Handler: mux}
+			servers = append(servers, srv)
+			go srv.Serve(ln)
+		}
+
+		return serverStartedMsg{servers: servers}
+	}
+}
+
+// startAPIServer serves the stored webhooks as JSON on localhost:
+// GET /api/webhooks?page=0&filter=... lists a page, newest first, and
+// GET /api/webhooks/{id} returns one webhook
+func startAPIServer(port int) tea.Cmd {
+	return func() tea.Msg {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/api/webhooks", apiListWebhooks)
+		mux.HandleFunc("/api/webhooks/", apiGetWebhook)
+
+		srv := &http.Server{Addr: fmt.Sprintf("127.0.0.1:%d", port), Handler: mux}
+		ln, err := net.Listen("tcp", srv.Addr)
+		if err != nil {
+			return apiErrorMsg(fmt.Sprintf("Failed to start the API on %s: %v", srv.Addr, err))
+		}
+		go srv.Serve(ln)
+		return apiStartedMsg{server: srv}
+	}
+}
+
+// apiListWebhooks returns one page of webhooks, like the list view
+func apiListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	page := 0
+	if v := r.URL.Query().Get("page"); v != "" {
+		var err error
+		if page, err = strconv.Atoi(v); err != nil || page < 0 {
+			http.Error(w, "invalid page", http.StatusBadRequest)
+			return
+		}
+	}
+	filter, err := parseFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	total, err := countWebhooks(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	size := config.pageSize()
+	webhooks, err := queryWebhooks(filter, size, page*size)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if webhooks == nil {
+		webhooks = []WebhookPayload{}
+	}
+	writeAPIJSON(w, map[string]interface{}{
+		"webhooks": webhooks,
+		"total":    total,
+		"page":     page,
+		"pages":    (total + size - 1) / size,
+	})
+}
+
+// apiGetWebhook returns the webhook whose id ends the path
+func apiGetWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/webhooks/"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	wh, err := loadWebhookByID(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeAPIJSON(w, wh)
+}
+
+// writeAPIJSON sends v as indented JSON
+func writeAPIJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// localPort is the port of the listener a request arrived on, "" for a Unix socket
+func localPort(r *http.Request) string {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if !ok {
+		return ""
+	}
+	if _, port, err := net.SplitHostPort(addr.String()); err == nil {
+		return port
+	}
+	return ""
+}
+
+// removeSocket deletes a Unix socket file, leaving anything else at the path alone
+func removeSocket(path string) {
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+}
+
+func waitForWebhook(ch chan WebhookPayload) tea.Cmd {
+	return func() tea.Msg {
+		payload := <-ch
+		return webhookReceivedMsg(payload)
+	}
+}
+
+// pingTunnel sends a request through the public tunnel URL to our own
+// readiness endpoint and checks that the echoed token comes back
+func pingTunnel(tunnelURL string) error {
+	token := strconv.FormatInt(time.Now().UnixNano(), 36)
+	req, err := http.NewRequest("GET", strings.TrimSuffix(tunnelURL, "/")+readinessPath+"?token="+token, nil)
+	if err != nil {
+		return err
+	}
+	// Skip the localtunnel reminder and ngrok browser warning pages
+	req.Header.Set("Bypass-Tunnel-Reminder", "true")
+	req.Header.Set("Ngrok-Skip-Browser-Warning", "true")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != token {
+		return fmt.Errorf("unexpected response (HTTP %d)", resp.StatusCode)
+	}
+	return nil
+}
+
+func verifyTunnel(tunnelURL string) tea.Cmd {
+	return func() tea.Msg {
+		if err := pingTunnel(tunnelURL); err != nil {
+			return tunnelVerifyFailedMsg(err.Error())
+		}
+		return tunnelVerifiedMsg{}
+	}
+}
+
+// healthCheckInterval is the configured tunnel ping period (0 = disabled)
+func healthCheckInterval() time.Duration {
+	switch {
+	case config.HealthCheckSeconds < 0:
+		return 0
+	case config.HealthCheckSeconds == 0:
+		return defaultHealthCheck
+	default:
+		return time.Duration(config.HealthCheckSeconds) * time.Second
+	}
+}
+
+func scheduleHealthCheck() tea.Cmd {
+	return tea.Tick(healthCheckInterval(), func(time.Time) tea.Msg {
+		return healthCheckTickMsg{}
+	})
+}
+
+func checkTunnelHealth(tunnelURL string) tea.Cmd {
+	return func() tea.Msg {
+		return healthCheckMsg{err: pingTunnel(tunnelURL)}
+	}
+}
+
+// startVerification kicks off tunnel verification once both server and tunnel are up
+func (m *Model) startVerification() tea.Cmd {
+	if !m.serverRunning || !m.tunnelRunning || m.tunnelVerified || m.verifying {
+		return nil
+	}
+	m.verifying = true
+	m.verifyError = ""
+	m.verifyAttempts++
+	return verifyTunnel(m.tunnelURL)
+}
+
+// idleTimeout is the configured idle auto-quit period (0 = disabled)
+func idleTimeout() time.Duration {
+	return time.Duration(config.IdleTimeoutMinutes) * time.Minute
+}
+
+func scheduleIdleCheck() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return idleCheckMsg{}
+	})
+}
+
+// stopTunnel kills the tunnel process and its children. Forgetting the
+// process marks its exit as clean, so no tunnelDiedMsg is acted on.
+func (m *Model) stopTunnel() {
+	if m.tunnelCmd != nil && m.tunnelCmd.Process != nil {
+		// Kill the process group to also kill child processes
+		syscall.Kill(-m.tunnelCmd.Process.Pid, syscall.SIGTERM)
+		m.tunnelCmd.Process.Kill()
+	}
+	m.tunnelCmd = nil
+}
+
+// shutdownServers shuts the webhook servers down, letting in-flight requests finish
+func shutdownServers(servers []*http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, srv := range servers {
+		srv.Shutdown(ctx)
+	}
+}
+
+// stopServers shuts the webhook servers down in the background, since
+// in-flight requests can hold it up for seconds
+func stopServers(servers []*http.Server) tea.Cmd {
+	return func() tea.Msg {
+		shutdownServers(servers)
+		return serversStoppedMsg{}
+	}
+}
+
+// shutdown stops the tunnel, the webhook servers and the API before quitting
+func (m *Model) shutdown() {
+	m.stopTunnel()
+	shutdownServers(m.servers)
+	if m.apiServer != nil {
+		m.apiServer.Close()
+	}
+}
+
+// parsePorts splits the setup screen's comma-separated port list. Empty
+// means the default port; the tunnel forwards to the first one.
+func parsePorts(input string) ([]string, error) {
+	if strings.TrimSpace(input) == "" {
+		return []string{"8098"}, nil
+	}
+	var ports []string
+	seen := map[string]bool{}
+	for _, p := range strings.Split(input, ",") {
+		p = strings.TrimSpace(p)
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid port %q", p)
+		}
+		if seen[p] {
+			return nil, fmt.Errorf("port %s listed twice", p)
+		}
+		seen[p] = true
+		ports = append(ports, p)
+	}
+	return ports, nil
+}
+
+// parseTunnelTimeout accepts Go durations ("90s", "2h") or, as before, a bare
+// number of minutes. Empty means the default.
+func parseTunnelTimeout(input string) (time.Duration, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return defaultTunnelTimeout, nil
+	}
+	if minutes, err := strconv.Atoi(input); err == nil {
+		if minutes <= 0 {
+			return 0, fmt.Errorf("timeout must be positive")
+		}
+		return time.Duration(minutes) * time.Minute, nil
+	}
+	d, err := time.ParseDuration(input)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q (use e.g. 90s, 45m or 2h)", input)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("timeout must be positive")
+	}
+	return d, nil
+}
+
+func scheduleTunnelExpiration(timeout time.Duration) tea.Cmd {
+	return tea.Tick(timeout, func(t time.Time) tea.Msg {
+		return tunnelExpiredMsg{}
+	})
+}
+
+// tunnelRemaining is the time left before the tunnel auto-shuts down, not
+// counting time spent paused
+func (m Model) tunnelRemaining() time.Duration {
+	paused := m.expiryPausedFor
+	if !m.expiryPausedAt.IsZero() {
+		paused += time.Since(m.expiryPausedAt)
+	}
+	return m.tunnelTimeout - time.Since(m.tunnelStartTime) + paused
+}
+
+// syncExpiryPause pauses the expiry countdown while a webhook is open in the
+// detail view (when enabled) and reschedules the expiry check on resume
+func (m *Model) syncExpiryPause() tea.Cmd {
+	pause := config.PauseExpiryInDetail && m.state == StateDetail && m.tunnelRunning
+	switch {
+	case pause && m.expiryPausedAt.IsZero():
+		m.expiryPausedAt = time.Now()
+	case !pause && !m.expiryPausedAt.IsZero():
+		m.expiryPausedFor += time.Since(m.expiryPausedAt)
+		m.expiryPausedAt = time.Time{}
+		return scheduleTunnelExpiration(m.tunnelRemaining())
+	}
+	return nil
+}
+
+// activityMark bookmarks the newest webhook so later arrivals can be summarized
+type activityMark struct {
+	id int
+	at time.Time
+}
+
+// markCount is one row of a grouped count
+type markCount struct {
+	key   string
+	count int
+}
+
+// sinceMarkSummary is everything received after the mark
+type sinceMarkSummary struct {
+	total    int
+	byMethod []markCount
+	byPath   []markCount
+	recent   []WebhookPayload
+}
+
+// maxSinceMarkRecent caps the webhooks listed on the since-mark screen
+const maxSinceMarkRecent = 20
+
+// setMark bookmarks the newest stored webhook
+func setMark() tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+		var id int
+		if err := db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM webhooks").Scan(&id); err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to set mark: %v", err))
+		}
+		return markSetMsg{id: id, at: time.Now()}
+	}
+}
+
+// loadSinceMark summarizes webhooks stored after the mark
+func loadSinceMark(mark activityMark) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return dbErrorMsg("Database not initialized")
+		}
+
+		summary := &sinceMarkSummary{}
+		for _, group := range []struct {
+			column string
+			into   *[]markCount
+		}{
+			{"method", &summary.byMethod},
+			{"path", &summary.byPath},
+		} {
+			rows, err := db.Query("SELECT "+group.column+", COUNT(*) AS n FROM webhooks WHERE id > ? GROUP BY "+group.column+" ORDER BY n DESC, "+group.column, mark.id)
+			if err != nil {
+				return dbErrorMsg(fmt.Sprintf("Failed to load activity since mark: %v", err))
+			}
+			for rows.Next() {
+				var c markCount
+				if err := rows.Scan(&c.key, &c.count); err == nil {
+					*group.into = append(*group.into, c)
+				}
+			}
+			rows.Close()
+		}
+		for _, c := range summary.byMethod {
+			summary.total += c.count
+		}
+
+		rows, err := db.Query("SELECT "+webhookColumns+" FROM webhooks WHERE id > ? ORDER BY id DESC LIMIT ?", mark.id, maxSinceMarkRecent)
+		if err != nil {
+			return dbErrorMsg(fmt.Sprintf("Failed to load activity since mark: %v", err))
+		}
+		defer rows.Close()
+		for rows.Next() {
+			if wh, err := scanWebhook(rows); err == nil {
+				summary.recent = append(summary.recent, wh)
+			}
+		}
+		return sinceMarkLoadedMsg(summary)
+	}
+}
+
+// busy reports whether anything on screen is waiting and needs the spinner
+func (m Model) busy() bool {
+	if m.fetchingIP || m.verifying || m.reconnecting {
+		return true
+	}
+	switch m.state {
+	case StateRunning:
+		return (!m.serverRunning && m.serverError == "") ||
+			(!m.tunnelRunning && !m.tunnelExpired && m.tunnelError == "" && config.ListenSocket == "" && !m.outsideHours)
+	case StateStats:
+		return m.stats == nil
+	case StateInfo:
+		return m.info == nil
+	case StateCleanup:
+		return m.cleanup == nil
+	case StateSinceMark:
+		return m.sinceMark == nil
+	}
+	return false
+}
+
+// rateWindow counts events per second over the last minute in a ring buffer
+type rateWindow struct {
+	counts [60]int
+	last   int64 // unix second of the newest bucket
+}
+
+// advance clears the buckets for seconds that passed without events
+func (r *rateWindow) advance(now time.Time) {
+	sec := now.Unix()
+	if sec-r.last >= int64(len(r.counts)) {
+		r.counts = [60]int{}
+	} else {
+		for s := r.last + 1; s <= sec; s++ {
+			r.counts[s%int64(len(r.counts))] = 0
+		}
+	}
+	if sec > r.last {
+		r.last = sec
+	}
+}
+
+func (r *rateWindow) add(now time.Time) {
+	r.advance(now)
+	r.counts[now.Unix()%int64(len(r.counts))]++
+}
+
+// series returns the per-second counts, oldest first, ending at now
+func (r rateWindow) series(now time.Time) []int {
+	r.advance(now)
+	out := make([]int, len(r.counts))
+	for i := range out {
+		out[i] = r.counts[(r.last+1+int64(i))%int64(len(r.counts))]
+	}
+	return out
+}
+
+// sparkBlocks are the sparkline levels, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// renderSparkline draws counts scaled to the largest; quiet seconds stay dim
+func renderSparkline(counts []int) string {
+	peak := 0
+	for _, c := range counts {
+		peak = max(peak, c)
+	}
+	var b strings.Builder
+	for _, c := range counts {
+		if c == 0 {
+			b.WriteString(infoStyle.Render(string(sparkBlocks[0])))
+			continue
+		}
+		level := (c*(len(sparkBlocks)-1) + peak - 1) / peak
+		b.WriteString(successStyle.Render(string(sparkBlocks[level])))
+	}
+	return b.String()
+}
+
+// restartTunnel starts the tunnel again, together with the webhook servers
+// when they were stopped with an expired tunnel
+func (m *Model) restartTunnel() tea.Cmd {
+	cmd := startTunnel(m.provider(), m.requestedPort, m.requestedSubdomain)
+	if !m.serverStopped {
+		return cmd
+	}
+	m.serverStopped = false
+	return tea.Batch(cmd, m.startWebhookServer())
+}
+
+// maxReconnectDelay caps the backoff between failed auto_reconnect attempts
+const maxReconnectDelay = time.Minute
+
+// scheduleReconnect doubles the backoff and retries the tunnel after it
+func (m *Model) scheduleReconnect() tea.Cmd {
+	m.reconnectDelay = min(max(2*m.reconnectDelay, time.Second), maxReconnectDelay)
+	return tea.Tick(m.reconnectDelay, func(time.Time) tea.Msg { return reconnectMsg{} })
+}
+
+// scheduleClockTick redraws once a second for the expiry and idle countdowns
+// and the relative ages in the list
+func scheduleClockTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return clockTickMsg{}
+	})
+}
+
+// Update wraps update so the spinner only ticks while something is pending;
+// an idle session then costs one redraw per second for the countdown
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	nm := next.(Model)
+	if resume := nm.syncExpiryPause(); resume != nil {
+		cmd = tea.Batch(cmd, resume)
+	}
+	if !nm.spinning && nm.busy() {
+		nm.spinning = true
+		cmd = tea.Batch(cmd, nm.spinner.Tick)
+	}
+	return nm, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.statusMsg = ""
+		m.statusErr = false
+		m.lastKeyAt = time.Now()
+
+		if m.paletteMode {
+			matches := m.paletteMatches()
+			switch msg.String() {
+			case "esc", "ctrl+p":
+				m.paletteMode = false
+				m.paletteInput.Blur()
+				return m, nil
+			case "up", "ctrl+k":
+				if m.paletteIdx > 0 {
+					m.paletteIdx--
+				}
+				return m, nil
+			case "down", "ctrl+j":
+				if m.paletteIdx < len(matches)-1 {
+					m.paletteIdx++
+				}
+				return m, nil
+			case "enter":
+				m.paletteMode = false
+				m.paletteInput.Blur()
+				if m.paletteIdx >= len(matches) {
+					return m, nil
+				}
+				// Run the action as if its key had been pressed
+				return m.update(matches[m.paletteIdx].keyMsg())
+			default:
+				var cmd tea.Cmd
+				m.paletteInput, cmd = m.paletteInput.Update(msg)
+				m.paletteIdx = 0
+				return m, cmd
+			}
+		}
+		if msg.String() == "ctrl+p" && !m.typing() && m.state != StateSetup {
+			m.paletteMode = true
+			m.paletteIdx = 0
+			m.paletteInput.SetValue("")
+			m.paletteInput.Focus()
+			return m, textinput.Blink
+		}
+
+		// Handle search mode input first
+		if m.searchMode {
+			switch msg.String() {
+			case "ctrl+r":
+				m.searchRegex = !m.searchRegex
+				m.searchInput.Prompt = searchPrompt(m.searchRegex)
+				m.searchErr = ""
+				return m, nil
+			case "enter":
+				// Execute search; an invalid regex keeps the input open to fix it
+				re, err := compileSearch(m.searchInput.Value(), m.searchRegex)
+				if err != nil {
+					m.searchErr = err.Error()
+					return m, nil
+				}
+				m.searchMode = false
+				m.searchQuery = m.searchInput.Value()
+				m.searchRe = re
+				m.searchInput.Blur()
+				if m.searchQuery != "" {
+					m.findSearchMatches()
+					m.updateDetailViewport() // Re-render with highlighting
+					if len(m.searchMatches) > 0 {
+						m.searchMatchIdx = 0
+						m.viewport.SetYOffset(m.searchMatches[0])
+					}
+					cmds = append(cmds, tea.ClearScreen)
+				}
+				return m, tea.Batch(cmds...)
+			case "esc":
+				// Cancel search
+				m.searchMode = false
+				m.searchErr = ""
+				m.searchInput.Blur()
+				m.searchInput.SetValue("")
+				// Clear highlighting
+				m.searchQuery = ""
+				m.searchRe = nil
+				m.searchMatches = nil
+				m.updateDetailViewport()
+				cmds = append(cmds, tea.ClearScreen)
+				return m, tea.Batch(cmds...)
+			default:
+				// Pass to search input
+				var cmd tea.Cmd
+				m.searchInput, cmd = m.searchInput.Update(msg)
+				m.searchErr = ""
+				return m, cmd
+			}
+		}
+
+		// Handle jq expression input
+		if m.jqMode {
+			switch msg.String() {
+			case "enter":
+				m.jqMode = false
+				m.jqInput.Blur()
+				m.jqExpr = strings.TrimSpace(m.jqInput.Value())
+				if m.jqExpr == "" || m.selectedIdx >= len(m.webhooks) {
+					return m, nil
+				}
+				return m, runJQ(m.webhooks[m.selectedIdx].Body, m.jqExpr)
+			case "esc":
+				m.jqMode = false
+				m.jqInput.Blur()
+				return m, nil
+			default:
+				var cmd tea.Cmd
+				m.jqInput, cmd = m.jqInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		// Handle path query input
+		if m.pathMode {
+			switch msg.String() {
+			case "enter":
+				m.pathMode = false
+				m.pathInput.Blur()
+				m.pathExpr = strings.TrimSpace(m.pathInput.Value())
+				if m.pathExpr != "" && m.selectedIdx < len(m.webhooks) {
+					m.showPathResult(m.webhooks[m.selectedIdx].BodyJSON, m.pathExpr)
+				}
+				return m, nil
+			case "esc":
+				m.pathMode = false
+				m.pathInput.Blur()
+				return m, nil
+			default:
+				var cmd tea.Cmd
+				m.pathInput, cmd = m.pathInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		// Handle replay target input
+		if m.replayMode {
+			switch msg.String() {
+			case "enter":
+				m.replayMode = false
+				m.replayInput.Blur()
+				m.replayTarget = strings.TrimSpace(m.replayInput.Value())
+				if m.replayTarget == "" || m.selectedIdx >= len(m.webhooks) {
+					return m, nil
+				}
+				m.statusMsg = "Replaying to " + m.replayTarget + "..."
+				return m, replayToTarget(m.webhooks[m.selectedIdx], m.replayTarget)
+			case "esc":
+				m.replayMode = false
+				m.replayInput.Blur()
+				return m, nil
+			default:
+				var cmd tea.Cmd
+				m.replayInput, cmd = m.replayInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		// Handle filter input
+		if m.filterMode {
+			switch msg.String() {
+			case "enter":
+				filter, err := parseFilter(m.filterInput.Value())
+				if err != nil {
+					// Keep the input open so the query can be fixed
+					m.statusMsg = err.Error()
+					m.statusErr = true
+					return m, nil
+				}
+				m.filterMode = false
+				m.filterInput.Blur()
+				m.filter = filter
+				m.currentPage = 0
+				return m, loadWebhooksFromDB(0, m.pageSize, m.filter)
+			case "esc":
+				m.filterMode = false
+				m.filterInput.Blur()
+				return m, nil
+			default:
+				var cmd tea.Cmd
+				m.filterInput, cmd = m.filterInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		// Handle cleanup confirmation
+		if m.confirmCleanup {
+			m.confirmCleanup = false
+			if msg.String() == "y" || msg.String() == "Y" {
+				return m, deleteCleanupBucket(m.cleanup[m.cleanupIdx])
+			}
+			m.statusMsg = "Cleanup cancelled"
+			return m, nil
+		}
+
+		// Handle delete confirmation
+		if m.confirmDeleteFilter {
+			m.confirmDeleteFilter = false
+			if msg.String() == "y" || msg.String() == "Y" {
+				return m, deleteFilteredWebhooks(m.filter)
+			}
+			m.statusMsg = "Delete cancelled"
+			return m, nil
+		}
+
+		if m.confirmPurge {
+			m.confirmPurge = false
+			if msg.String() == "y" || msg.String() == "Y" {
+				return m, deleteCleanupBucket(retentionBucket(config.RetainDays, time.Now()))
+			}
+			m.statusMsg = "Purge cancelled"
+			return m, nil
+		}
+
+		if m.confirmDeleteID != 0 {
+			id := m.confirmDeleteID
+			m.confirmDeleteID = 0
+			if msg.String() == "y" || msg.String() == "Y" {
+				return m, deleteWebhook(id)
+			}
+			m.statusMsg = "Delete cancelled"
+			return m, nil
+		}
+
+		// The provider field on the setup screen is a selector
+		if m.state == StateSetup && m.focusedInput == setupProviderField {
+			switch msg.String() {
+			case "left":
+				m.providerIdx = (m.providerIdx + len(tunnelProviders) - 1) % len(tunnelProviders)
+				return m, nil
+			case "right", " ":
+				m.providerIdx = (m.providerIdx + 1) % len(tunnelProviders)
+				return m, nil
+			}
+		}
+
+		switch msg.String() {
+		case "ctrl+c", "q":
+			m.shutdown()
+			return m, tea.Quit
+
+		case "tab", "shift+tab":
+			if m.state == StateStats {
+				// Switch the histogrammed metric
+				m.statsMetric = (m.statsMetric + 1) % len(histogramMetrics)
+				cmds = append(cmds, loadStats(m.filter, m.statsMetric))
+			} else if m.state == StateSetup {
+				if msg.String() == "shift+tab" {
+					m.focusedInput = (m.focusedInput + setupFields - 1) % setupFields // Go backwards
+				} else {
+					m.focusedInput = (m.focusedInput + 1) % setupFields
+				}
+				// Update focus states
+				m.portInput.Blur()
+				m.subdomainInput.Blur()
+				m.timeoutInput.Blur()
+				switch m.focusedInput {
+				case 0:
+					m.portInput.Focus()
+				case 1:
+					m.subdomainInput.Focus()
+				case 2:
+					m.timeoutInput.Focus()
+				}
+				// Field 3, the provider, is a selector rather than a text input
+			}
+
+		case "enter":
+			if m.state == StateSetup {
+				// Validate the timeout before starting anything
+				timeout, err := parseTunnelTimeout(m.timeoutInput.Value())
+				if err != nil {
+					m.statusMsg = err.Error()
+					m.statusErr = true
+					return m, nil
+				}
+				m.tunnelTimeout = timeout
+
+				ports, err := parsePorts(m.portInput.Value())
+				if err != nil {
+					m.statusMsg = err.Error()
+					m.statusErr = true
+					return m, nil
+				}
+
+				if config.APIPort != 0 && config.ListenSocket == "" {
+					for _, p := range ports {
+						if p == strconv.Itoa(config.APIPort) {
+							m.statusMsg = fmt.Sprintf("Port %s is the api_port; pick another for webhooks", p)
+							m.statusErr = true
+							return m, nil
+						}
+					}
+				}
+
+				m.state = StateRunning
+				port := ports[0]
+				subdomain := m.subdomainInput.Value()
+
+				// Store for display
+				m.requestedPort = port
+				m.ports = ports
+				m.requestedSubdomain = subdomain
+				cmds = append(cmds, saveConfigCmd("setup defaults", setupDefaults(m.portInput.Value(), subdomain, timeout, m.provider().Name())))
+				if config.ActiveHours != nil && config.ListenSocket == "" {
+					active, _ := config.ActiveHours.at(time.Now())
+					m.outsideHours = !active
+					cmds = append(cmds, scheduleActiveHoursCheck())
+				}
+				if config.ListenSocket == "" && !m.outsideHours {
+					cmds = append(cmds, startTunnel(m.provider(), port, subdomain))
+				}
+				cmds = append(cmds, m.startWebhookServer())
+				if config.APIPort != 0 {
+					cmds = append(cmds, startAPIServer(config.APIPort))
+				}
+			} else if m.state == StateRunning && len(m.webhooks) > 0 {
+				cmds = append(cmds, m.openDetail())
+			}
+
+		case "i":
+			if m.state == StateRunning {
+				m.state = StateInfo
+				cmds = append(cmds, loadInfo())
+			} else if m.state == StateInfo {
+				m.state = StateRunning
+			}
+
+		case "H":
+			if m.state == StateRunning {
+				m.state = StateHistory
+				cmds = append(cmds, loadHistory())
+			} else if m.state == StateHistory {
+				m.state = StateRunning
+			}
+
+		case "X":
+			if m.state == StateRunning {
+				m.state = StateCleanup
+				m.cleanup = nil
+				cmds = append(cmds, loadCleanup())
+			} else if m.state == StateCleanup {
+				m.state = StateRunning
+			}
+
+		case "m":
+			if m.state == StateRunning {
+				cmds = append(cmds, setMark())
+			}
+
+		case "'":
+			if m.state == StateRunning && m.mark != nil {
+				m.state = StateSinceMark
+				m.sinceMark = nil
+				cmds = append(cmds, loadSinceMark(*m.mark))
+			} else if m.state == StateRunning {
+				m.statusMsg = "No mark set; press m first"
+			} else if m.state == StateSinceMark {
+				m.state = StateRunning
+			}
+
+		case "d":
+			if m.state == StateCleanup && m.cleanupIdx < len(m.cleanup) && m.cleanup[m.cleanupIdx].count > 0 {
+				m.confirmCleanup = true
+			} else if m.state == StateRunning && m.selectedIdx < len(m.webhooks) {
+				m.confirmDeleteID = m.webhooks[m.selectedIdx].ID
+			}
+
+		case "S":
+			if m.state == StateRunning {
+				m.state = StateStats
+				cmds = append(cmds, loadStats(m.filter, m.statsMetric))
+			} else if m.state == StateStats {
+				m.state = StateRunning
+			}
+
+		case "esc":
+			if m.state == StateStats || m.state == StateHistory || m.state == StateInfo || m.state == StateCleanup || m.state == StateSinceMark {
+				m.state = StateRunning
+			} else if m.state == StateDetail && m.jqResult != "" {
+				// Back from jq output to the details
+				m.refreshDetail()
+				m.viewport.GotoTop()
+			} else if m.state == StateDetail {
+				m.state = StateRunning
+				// Clear search when leaving detail view
+				m.searchQuery = ""
+				m.searchRe = nil
+				m.searchMatches = nil
+				m.searchMatchIdx = 0
+			} else if m.state == StateRunning && m.filter.active() {
+				// Clear all active filters
+				m.filter = webhookFilter{}
+				m.currentPage = 0
+				cmds = append(cmds, loadWebhooksFromDB(0, m.pageSize, m.filter))
+			}
+
+		case "R":
+			if m.state == StateDetail {
+				m.replayMode = true
+				m.replayInput.SetValue(m.replayTarget)
+				m.replayInput.CursorEnd()
+				m.replayInput.Focus()
+				return m, textinput.Blink
+			}
+
+		case ":":
+			if m.state == StateDetail {
+				m.pathMode = true
+				m.pathInput.SetValue(m.pathExpr)
+				m.pathInput.CursorEnd()
+				m.pathInput.Focus()
+				return m, textinput.Blink
+			}
+
+		case "|":
+			if m.state == StateDetail {
+				m.jqMode = true
+				m.jqInput.SetValue(m.jqExpr)
+				m.jqInput.CursorEnd()
+				m.jqInput.Focus()
+				return m, textinput.Blink
+			}
+
+		case "/":
+			if m.state == StateDetail {
+				m.searchMode = true
+				m.searchInput.SetValue("")
+				m.searchInput.Focus()
+				return m, textinput.Blink
+			} else if m.state == StateRunning {
+				m.filterMode = true
+				m.filterInput.SetValue(m.filter.query())
+				m.filterInput.CursorEnd()
+				m.filterInput.Focus()
+				return m, textinput.Blink
+			}
+
+		case " ":
+			// Toggle the processed flag of the selected webhook
+			if m.state == StateRunning && m.selectedIdx < len(m.webhooks) {
+				wh := &m.webhooks[m.selectedIdx]
+				wh.Processed = !wh.Processed
+				cmds = append(cmds, setProcessed(wh.ID, wh.Processed))
+				if wh.Processed && m.filter.HideProcessed {
+					// It no longer matches the filter
+					cmds = append(cmds, loadWebhooksFromDB(m.currentPage, m.pageSize, m.filter))
+				}
+			}
+
+		case "u":
+			// Toggle hiding processed webhooks
+			if m.state == StateRunning {
+				m.filter.HideProcessed = !m.filter.HideProcessed
+				m.currentPage = 0
+				cmds = append(cmds, loadWebhooksFromDB(0, m.pageSize, m.filter))
+			}
+
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			// Clear a single filter by its number in the summary line
+			if m.state == StateRunning {
+				idx := int(msg.String()[0] - '1')
+				if clauses := m.filter.clauses(); idx < len(clauses) {
+					clauses[idx].clear(&m.filter)
+					m.currentPage = 0
+					cmds = append(cmds, loadWebhooksFromDB(0, m.pageSize, m.filter))
+				}
+			}
+
+		case "B":
+			if m.state == StateRunning {
+				m.statusMsg = "Exporting bundle..."
+				cmds = append(cmds, exportBundle(m.filter, m.sessionMeta(), m.baseURL()))
+			}
+
+		case "e":
+			// Dump the whole database, one JSON object per line
+			if m.state == StateRunning {
+				m.statusMsg = "Exporting..."
+				name := fmt.Sprintf("export-%s.ndjson", time.Now().Format("20060102-150405"))
+				cmds = append(cmds, exportToFile("ndjson", name, exportOptions{}))
+			}
+
+		case "h":
+			// Everything as HAR, for loading into browser devtools
+			if m.state == StateRunning {
+				host := config.HARHost
+				if host == "" {
+					host = m.baseURL()
+				}
+				m.statusMsg = "Exporting..."
+				cmds = append(cmds, exportToFile("har", "export.har", exportOptions{baseURL: host}))
+			}
+
+		case "P":
+			// Apply retain_days now (requires confirmation)
+			if m.state == StateRunning && config.RetainDays <= 0 {
+				m.statusMsg = "Set retain_days in the config to purge old webhooks"
+				m.statusErr = true
+			} else if m.state == StateRunning {
+				m.confirmPurge = true
+			}
+
+		case "D":
+			// Delete everything matching the active filter (requires confirmation)
+			if m.state == StateRunning && m.filter.active() && m.totalWebhooks > 0 {
+				m.confirmDeleteFilter = true
+			}
+
+		case "o":
+			// Toggle a 500 response for the selected webhook's path
+			if (m.state == StateRunning || m.state == StateDetail) && m.selectedIdx < len(m.webhooks) {
+				path := m.webhooks[m.selectedIdx].Path
+				if m.overrides.toggle(path, http.StatusInternalServerError) {
+					m.statusMsg = fmt.Sprintf("%s will now return 500", path)
+				} else {
+					m.statusMsg = fmt.Sprintf("%s restored to default response", path)
+				}
+			}
+
+		case "y":
+			if m.state == StateDetail && m.selectedIdx < len(m.webhooks) {
+				cmds = append(cmds, copyToClipboard(buildCurl(m.webhooks[m.selectedIdx], m.baseURL()), "curl command"))
+			}
+
+		case "b":
+			// Copy the body; JSON in the current pretty/compact mode
+			if m.state == StateDetail && m.selectedIdx < len(m.webhooks) {
+				wh := m.webhooks[m.selectedIdx]
+				body := wh.Body
+				if wh.BodyJSON != nil {
+					if out, err := m.formatBodyJSON(wh); err == nil {
+						body = string(out)
+					}
+				}
+				cmds = append(cmds, copyToClipboard(body, "body"))
+			}
+
+		case "M":
+			if m.state == StateDetail && m.selectedIdx < len(m.webhooks) {
+				cmds = append(cmds, copyToClipboard(buildMarkdown(m.webhooks[m.selectedIdx]), "webhook as markdown"))
+			} else if m.state == StateRunning {
+				// Step the method filter; it is part of the DB query so every page is filtered
+				m.filter.Method = nextMethodFilter(m.filter.Method)
+				m.currentPage = 0
+				cmds = append(cmds, loadWebhooksFromDB(0, m.pageSize, m.filter))
+			}
+
+		case "J":
+			if m.state == StateDetail {
+				m.compactJSON = !m.compactJSON
+				m.refreshDetail()
+				cmds = append(cmds, tea.ClearScreen)
+			}
+
+		case "x":
+			if m.state == StateDetail {
+				m.hexMode = !m.hexMode
+				m.refreshDetail()
+				cmds = append(cmds, tea.ClearScreen)
+			}
+
+		case "a":
+			if m.state == StateDetail {
+				m.arrayMode = !m.arrayMode
+				m.refreshDetail()
+				cmds = append(cmds, tea.ClearScreen)
+			}
+
+		case "[", "]":
+			if m.state == StateDetail && m.arrayMode && m.selectedIdx < len(m.webhooks) {
+				if elems, ok := m.webhooks[m.selectedIdx].BodyJSON.([]interface{}); ok {
+					pages := (len(elems) + arrayPageSize - 1) / arrayPageSize
+					if msg.String() == "]" && m.arrayPage < pages-1 {
+						m.arrayPage++
+					} else if msg.String() == "[" && m.arrayPage > 0 {
+						m.arrayPage--
+					}
+					m.refreshDetail()
+					cmds = append(cmds, tea.ClearScreen)
+				}
+			}
+
+		case "N":
+			if m.state == StateDetail && len(m.searchMatches) > 0 {
+				// Previous match
+				m.searchMatchIdx = (m.searchMatchIdx - 1 + len(m.searchMatches)) % len(m.searchMatches)
+				m.viewport.SetYOffset(m.searchMatches[m.searchMatchIdx])
+				cmds = append(cmds, tea.ClearScreen)
+			}
+
+		case "up", "k":
+			m.stopFollowing()
+			if m.state == StateRunning && m.selectedIdx > 0 {
+				m.selectedIdx--
+			} else if m.state == StateCleanup && m.cleanupIdx > 0 {
+				m.cleanupIdx--
+			} else if m.state == StateDetail {
+				m.viewport.LineUp(1)
+				cmds = append(cmds, tea.ClearScreen)
+			}
+
+		case "down", "j":
+			m.stopFollowing()
+			if m.state == StateRunning && m.selectedIdx < len(m.webhooks)-1 {
+				m.selectedIdx++
+			} else if m.state == StateCleanup && m.cleanupIdx < len(m.cleanup)-1 {
+				m.cleanupIdx++
+			} else if m.state == StateDetail {
+				m.viewport.LineDown(1)
+				cmds = append(cmds, tea.ClearScreen)
+			}
+
+		case "c":
+			if m.state == StateRunning {
+				m.webhooksMu.Lock()
+				m.webhooks = make([]WebhookPayload, 0)
+				m.selectedIdx = 0
+				m.webhooksMu.Unlock()
+				m.droppedLive.Store(0)
+			}
+
+		case "E":
+			// Mark the selected webhook as the expected payload for its path, or unmark it
+			if (m.state == StateRunning || m.state == StateDetail) && m.selectedIdx < len(m.webhooks) {
+				wh := m.webhooks[m.selectedIdx]
+				if m.baselines == nil {
+					m.baselines = make(map[string]baseline)
+				}
+				if b, ok := m.baselines[wh.Path]; ok && b.webhookID == wh.ID {
+					delete(m.baselines, wh.Path)
+					cmds = append(cmds, deleteBaseline(wh.Path))
+					m.statusMsg = "Removed baseline for " + wh.Path
+				} else {
+					m.baselines[wh.Path] = newBaseline(wh.ID, wh.Body)
+					cmds = append(cmds, saveBaseline(wh))
+					m.statusMsg = fmt.Sprintf("#%d is now the baseline for %s", wh.ID, wh.Path)
+				}
+				if m.state == StateDetail {
+					m.refreshDetail()
+				}
+			}
+
+		case "f":
+			if m.state == StateRunning {
+				m.follow = !m.follow
+				if m.follow {
+					m.selectedIdx = 0
+				}
+			}
+
+		case "ctrl+n":
+			if m.state == StateRunning || m.state == StateDetail {
+				m.notify = !m.notify
+				if m.notify {
+					m.statusMsg = "Desktop notifications on"
+				} else {
+					m.statusMsg = "Desktop notifications off"
+					m.notifyHeld, m.notifySuppressed = false, 0
+				}
+			}
+
+		case "A":
+			if m.state == StateRunning || m.state == StateDetail {
+				m.autoOpen = !m.autoOpen
+				if m.autoOpen {
+					m.statusMsg = "New webhooks will open in the detail view"
+				} else {
+					m.statusMsg = "Auto-open disabled"
+				}
+			}
+
+		case "%":
+			// Toggle percent-decoded paths
+			if m.state == StateRunning || m.state == StateDetail {
+				m.decodePaths = !m.decodePaths
+				if m.state == StateDetail {
+					m.refreshDetail()
+				}
+			}
+
+		case "t":
+			if m.state == StateRunning {
+				if m.viewMode == ViewModeList {
 					m.viewMode = ViewModeTable
 				} else {
 					m.viewMode = ViewModeList
 				}
+				cmds = append(cmds, saveConfigCmd("view mode", map[string]interface{}{"view_mode": m.viewMode.String()}))
+			}
+
+		case "l":
+			if m.state == StateRunning {
+				cmds = append(cmds, loadWebhooksFromDB(0, m.pageSize, m.filter))
+			}
+
+		case "F":
+			if (m.state == StateRunning || m.state == StateDetail) && config.ForwardTo == "" {
+				m.statusMsg = "Set forward_to in the config to forward webhooks"
+				m.statusErr = true
+			} else if m.state == StateRunning || m.state == StateDetail {
+				paused := !m.forwardPaused.Load()
+				m.forwardPaused.Store(paused)
+				m.statusMsg = "Forwarding to " + config.ForwardTo + " resumed"
+				if paused {
+					m.statusMsg = "Forwarding to " + config.ForwardTo + " paused"
+				}
+			}
+
+		case "+", "-":
+			if m.state == StateRunning {
+				size := m.pageSize
+				if msg.String() == "+" {
+					size = min(size+pageSizeStep, maxPageSize)
+				} else {
+					size = max(size-pageSizeStep, minPageSize)
+				}
+				// Stay on the page holding the first webhook currently shown
+				first := m.currentPage * m.pageSize
+				m.pageSize = size
+				m.statusMsg = fmt.Sprintf("Page size: %d", size)
+				cmds = append(cmds,
+					saveConfigCmd("page size", map[string]interface{}{"page_size": size}),
+					loadWebhooksFromDB(first/size, size, m.filter))
+			}
+
+		case "r":
+			// Reconnect tunnel
+			if m.state == StateRunning && m.outsideHours {
+				_, next := config.ActiveHours.at(time.Now())
+				m.statusMsg = "Outside active hours; the tunnel starts " + next.Format("Mon 15:04")
+			} else if m.state == StateRunning && config.ListenSocket == "" && (m.tunnelExpired || !m.tunnelRunning) {
+				m.tunnelExpired = false
+				m.tunnelError = ""
+				cmds = append(cmds, m.restartTunnel())
+			} else if m.state == StateRunning && m.tunnelRunning && !m.tunnelVerified && !m.verifying {
+				// Retry a failed verification
+				m.verifyAttempts = 0
+				cmds = append(cmds, m.startVerification())
+			}
+
+		case "n":
+			if m.state == StateDetail && len(m.searchMatches) > 0 {
+				// Next search match
+				m.searchMatchIdx = (m.searchMatchIdx + 1) % len(m.searchMatches)
+				m.viewport.SetYOffset(m.searchMatches[m.searchMatchIdx])
+				cmds = append(cmds, tea.ClearScreen)
+			} else if m.state == StateRunning && m.currentPage < m.totalPages-1 {
+				m.currentPage++
+				cmds = append(cmds, loadWebhooksFromDB(m.currentPage, m.pageSize, m.filter))
+			}
+
+		case "right":
+			if m.state == StateRunning && m.currentPage < m.totalPages-1 {
+				m.currentPage++
+				cmds = append(cmds, loadWebhooksFromDB(m.currentPage, m.pageSize, m.filter))
+			}
+
+		case "p", "left":
+			if m.state == StateRunning && m.currentPage > 0 {
+				m.currentPage--
+				cmds = append(cmds, loadWebhooksFromDB(m.currentPage, m.pageSize, m.filter))
+			}
+
+		case "pgup":
+			if m.state == StateDetail {
+				m.viewport.HalfViewUp()
+				cmds = append(cmds, tea.ClearScreen)
+			}
+
+		case "pgdown":
+			if m.state == StateDetail {
+				m.viewport.HalfViewDown()
+				cmds = append(cmds, tea.ClearScreen)
+			}
+
+		case "ctrl+f":
+			if m.state == StateDetail {
+				m.viewport.ViewDown()
+				cmds = append(cmds, tea.ClearScreen)
+			}
+
+		case "ctrl+b":
+			if m.state == StateDetail {
+				m.viewport.ViewUp()
+				cmds = append(cmds, tea.ClearScreen)
+			}
+
+		case "ctrl+d":
+			if m.state == StateDetail {
+				m.viewport.HalfViewDown()
+				cmds = append(cmds, tea.ClearScreen)
+			}
+
+		case "ctrl+u":
+			if m.state == StateDetail {
+				m.viewport.HalfViewUp()
+				cmds = append(cmds, tea.ClearScreen)
+			}
+
+		case "G":
+			if m.state == StateDetail {
+				m.viewport.GotoBottom()
+				cmds = append(cmds, tea.ClearScreen)
+			} else if m.state == StateRunning && len(m.webhooks) > 0 {
+				m.stopFollowing()
+				m.selectedIdx = len(m.webhooks) - 1
+			}
+
+		case "g":
+			if m.state == StateDetail {
+				m.viewport.GotoTop()
+				cmds = append(cmds, tea.ClearScreen)
+			} else if m.state == StateRunning && len(m.webhooks) > 0 {
+				m.selectedIdx = 0
+			}
+		}
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		// Viewport height accounts for: header+blank (2) + blanks after viewport (2) + scroll indicator (1) + help (1) = 6 lines
+		if !m.viewportReady {
+			m.viewport = viewport.New(msg.Width-4, msg.Height-6)
+			m.viewport.HighPerformanceRendering = false
+			m.viewportReady = true
+		} else {
+			m.viewport.Width = msg.Width - 4
+			m.viewport.Height = msg.Height - 6
+		}
+		if m.state == StateSetup {
+			m.layoutSetupInputs(msg.Width)
+		}
+
+	case publicIPMsg:
+		m.publicIP = string(msg)
+		m.fetchingIP = false
+
+	case publicIPErrMsg:
+		m.publicIPAttempts++
+		if m.publicIPAttempts < maxPublicIPAttempts {
+			cmds = append(cmds, tea.Tick(2*time.Second, func(time.Time) tea.Msg {
+				return retryPublicIPMsg{}
+			}))
+		} else {
+			m.publicIP = "Unable to fetch"
+			m.fetchingIP = false
+		}
+
+	case retryPublicIPMsg:
+		cmds = append(cmds, fetchPublicIP)
+
+	case tunnelStartedMsg:
+		m.tunnelURL = msg.url
+		m.tunnelCmd = msg.cmd
+		m.tunnelRunning = true
+		m.reconnecting = false
+		m.tunnelExpired = false
+		m.tunnelStartTime = time.Now()
+		m.expiryPausedFor = 0
+		m.expiryPausedAt = time.Time{}
+		m.tunnelVerified = false
+		m.verifyAttempts = 0
+		cmds = append(cmds, recordSession(m.tunnelURL, m.publicIP, m.requestedPort))
+		// Schedule auto-shutdown
+		cmds = append(cmds, scheduleTunnelExpiration(m.tunnelTimeout))
+		if !m.clocking {
+			m.clocking = true
+			cmds = append(cmds, scheduleClockTick())
+		}
+		cmds = append(cmds, m.startVerification())
+		cmds = append(cmds, waitTunnelExit(msg.cmd, msg.exited))
+
+	case tunnelDiedMsg:
+		if msg.cmd == nil || msg.cmd != m.tunnelCmd {
+			// We killed it, or it was already replaced
+			break
+		}
+		m.tunnelCmd = nil
+		m.tunnelRunning = false
+		m.tunnelVerified = false
+		m.tunnelReachable = false
+		reason := "exited"
+		if msg.err != nil {
+			reason = msg.err.Error()
+		}
+		m.tunnelError = fmt.Sprintf("tunnel process died (%s)", reason)
+		if config.AutoReconnect {
+			m.reconnecting = true
+			cmds = append(cmds, m.scheduleReconnect())
+		} else {
+			m.tunnelError += " - press 'r' to reconnect"
+		}
+
+	case tunnelVerifiedMsg:
+		m.verifying = false
+		m.tunnelVerified = true
+		m.reconnectDelay = 0 // only a tunnel that works resets the backoff
+		m.verifyError = ""
+		m.tunnelReachable = true
+		m.healthFailures = 0
+		m.lastHealthCheck = time.Now()
+		// One check loop survives tunnel restarts; it idles while the tunnel is down
+		if !m.healthScheduled && healthCheckInterval() > 0 {
+			m.healthScheduled = true
+			cmds = append(cmds, scheduleHealthCheck())
+		}
+
+	case healthCheckTickMsg:
+		if !m.tunnelRunning || !m.tunnelVerified {
+			m.healthScheduled = false
+			break
+		}
+		cmds = append(cmds, checkTunnelHealth(m.tunnelURL))
+
+	case healthCheckMsg:
+		m.lastHealthCheck = time.Now()
+		if msg.err == nil {
+			m.healthFailures = 0
+			m.tunnelReachable = true
+		} else {
+			// Debounce so a single dropped request doesn't flip the indicator
+			m.healthFailures++
+			if m.healthFailures >= healthFailThreshold {
+				m.tunnelReachable = false
+			}
+		}
+		cmds = append(cmds, scheduleHealthCheck())
+
+	case activeHoursTickMsg:
+		active, _ := config.ActiveHours.at(time.Now())
+		if !active && !m.outsideHours {
+			m.outsideHours = true
+			if m.tunnelRunning {
+				m.stopTunnel()
+				m.tunnelRunning = false
+				m.tunnelVerified = false
+			}
+		} else if active && (m.outsideHours || m.tunnelExpired || m.tunnelError != "") {
+			// Window opened, or the tunnel went down during it
+			m.outsideHours = false
+			m.tunnelExpired = false
+			m.tunnelError = ""
+			cmds = append(cmds, m.restartTunnel())
+		}
+		cmds = append(cmds, scheduleActiveHoursCheck())
+
+	case tunnelVerifyFailedMsg:
+		m.verifying = false
+		m.verifyError = string(msg)
+		// The tunnel often needs a few seconds before it routes traffic
+		if m.tunnelRunning && m.verifyAttempts < maxVerifyAttempts {
+			cmds = append(cmds, tea.Tick(verifyRetryDelay, func(time.Time) tea.Msg {
+				return retryVerifyMsg{}
+			}))
+		}
+
+	case retryVerifyMsg:
+		cmds = append(cmds, m.startVerification())
+
+	case tunnelExpiredMsg:
+		if !m.tunnelRunning || m.tunnelExpired || !m.expiryPausedAt.IsZero() {
+			// Nothing to do, or paused - resuming reschedules the check
+			break
+		}
+		if remaining := m.tunnelRemaining(); remaining > 0 {
+			// Pauses (or a restarted tunnel) pushed the deadline back
+			cmds = append(cmds, scheduleTunnelExpiration(remaining))
+		} else if config.AutoReconnect {
+			// Replace the tunnel; the server keeps running throughout
+			m.stopTunnel()
+			m.tunnelRunning = false
+			m.tunnelVerified = false
+			m.reconnecting = true
+			cmds = append(cmds, m.restartTunnel())
+		} else {
+			// Kill the tunnel; the listener would only see local traffic now
+			m.stopTunnel()
+			m.tunnelRunning = false
+			m.tunnelExpired = true
+			m.tunnelVerified = false
+			m.serverRunning = false
+			cmds = append(cmds, stopServers(m.servers))
+		}
+
+	case serversStoppedMsg:
+		if m.tunnelExpired {
+			m.serverStopped = true
+		} else {
+			// Reconnected while the servers were shutting down
+			cmds = append(cmds, m.startWebhookServer())
+		}
+
+	case tunnelErrorMsg:
+		m.tunnelError = string(msg)
+		if m.reconnecting {
+			cmds = append(cmds, m.scheduleReconnect())
+		}
+
+	case reconnectMsg:
+		if m.reconnecting && !m.outsideHours {
+			m.tunnelError = ""
+			cmds = append(cmds, m.restartTunnel())
+		}
+
+	case serverStartedMsg:
+		restarted := m.servers != nil
+		m.serverRunning = true
+		m.servers = msg.servers
+		m.lastActivity = time.Now()
+		cmds = append(cmds, m.startVerification())
+		if !m.clocking {
+			// The traffic sparkline scrolls every second
+			m.clocking = true
+			cmds = append(cmds, scheduleClockTick())
+		}
+		if !restarted {
+			// The webhook reader and idle check keep running across restarts
+			cmds = append(cmds, waitForWebhook(m.webhookChan), waitForForward(m.forwardChan), waitForAlert(m.alertChan))
+			if idleTimeout() > 0 {
+				cmds = append(cmds, scheduleIdleCheck())
+			}
+		}
+
+	case idleCheckMsg:
+		if time.Since(m.lastActivity) >= idleTimeout() {
+			// Clean shutdown; the database is closed when the program exits
+			m.shutdown()
+			return m, tea.Quit
+		}
+		cmds = append(cmds, scheduleIdleCheck())
+
+	case serverErrorMsg:
+		m.serverError = string(msg)
+
+	case apiStartedMsg:
+		m.apiServer = msg.server
+
+	case apiErrorMsg:
+		m.statusMsg = string(msg)
+		m.statusErr = true
+
+	case webhookReceivedMsg:
+		m.lastActivity = time.Now()
+		m.traffic.add(m.lastActivity)
+		if m.notify {
+			summary := msg.Method + " " + msg.Path
+			if !m.notifyHeld {
+				m.notifyHeld = true
+				cmds = append(cmds, sendNotification("Webhook received", summary), scheduleNotifyWindow())
+			} else {
+				m.notifySuppressed++
+				m.notifyLatest = summary
+			}
+		}
+		if WebhookPayload(msg).oversizedHeaders() {
+			m.oversizedHeaders++
+		}
+		if m.filter.matches(WebhookPayload(msg)) {
+			m.webhooksMu.Lock()
+			selectedID := 0
+			if m.selectedIdx < len(m.webhooks) {
+				selectedID = m.webhooks[m.selectedIdx].ID
+			}
+			m.webhooks = insertByID(m.webhooks, WebhookPayload(msg))
+			if m.follow && m.state == StateRunning {
+				// Tail mode: stay on the newest
+				m.selectedIdx = 0
+			} else {
+				// Keep the same webhook selected while new ones are inserted above it
+				m.selectedIdx = selectionAfterReload(m.webhooks, selectedID)
+			}
+			m.webhooksMu.Unlock()
+
+			if m.autoOpen && m.idleInList() {
+				m.selectedIdx = selectionAfterReload(m.webhooks, msg.ID)
+				cmds = append(cmds, m.openDetail())
+			}
+		}
+		cmds = append(cmds, waitForWebhook(m.webhookChan))
+
+	case alertMsg:
+		text := fmt.Sprintf("Alert %s: #%d %s", msg.rule.name(), msg.id, msg.title)
+		m.statusMsg = text
+		m.statusErr = true
+		if msg.rule.Flash {
+			m.flashText = text
+			m.flashUntil = time.Now().Add(flashDuration)
+			cmds = append(cmds, scheduleFlashEnd())
+		} else {
+			cmds = append(cmds, m.ringBell())
+		}
+		cmds = append(cmds, waitForAlert(m.alertChan))
+
+	case bellEndMsg:
+		m.bell = false
+
+	case flashEndMsg:
+		// A later alert extends the flash; its own tick clears it
+		if !time.Now().Before(m.flashUntil) {
+			m.flashText = ""
+		}
+
+	case forwardResultMsg:
+		m.webhooksMu.Lock()
+		for i := range m.webhooks {
+			if m.webhooks[i].ID == msg.id {
+				m.webhooks[i].ForwardStatus, m.webhooks[i].ForwardError = msg.status, msg.err
+				if m.state == StateDetail && i == m.selectedIdx && m.jqResult == "" {
+					m.refreshDetail()
+				}
+			}
+		}
+		m.webhooksMu.Unlock()
+		cmds = append(cmds, waitForForward(m.forwardChan))
+
+	case webhooksLoadedMsg:
+		m.webhooksMu.Lock()
+		// Remember the selected webhook so a reload doesn't lose our place
+		selectedID := 0
+		if m.selectedIdx < len(m.webhooks) {
+			selectedID = m.webhooks[m.selectedIdx].ID
+		}
+		m.webhooks = msg.webhooks
+		m.totalWebhooks = msg.totalCount
+		m.currentPage = msg.currentPage
+		m.totalPages = (msg.totalCount + m.pageSize - 1) / m.pageSize
+		if m.totalPages == 0 {
+			m.totalPages = 1
+		}
+		m.selectedIdx = selectionAfterReload(m.webhooks, selectedID)
+		m.webhooksMu.Unlock()
+
+	case webhookDeletedMsg:
+		m.statusMsg = fmt.Sprintf("Deleted #%d", msg.id)
+		cmds = append(cmds, loadWebhooksFromDB(m.currentPage, m.pageSize, m.filter))
+
+	case webhooksDeletedMsg:
+		m.statusMsg = fmt.Sprintf("Deleted %d webhooks matching %s", msg.count, m.filter)
+		m.currentPage = 0
+		cmds = append(cmds, loadWebhooksFromDB(0, m.pageSize, m.filter))
+
+	case jqResultMsg:
+		if msg.err != nil {
+			m.statusMsg = msg.err.Error()
+			m.statusErr = true
+		} else if m.state == StateDetail {
+			m.showJQResult(msg.output)
+		}
+
+	case detailReplayMsg:
+		if msg.err != nil {
+			m.statusMsg = fmt.Sprintf("Replay of #%d to %s failed: %v", msg.id, msg.target, msg.err)
+			m.statusErr = true
+		} else {
+			m.statusMsg = fmt.Sprintf("Replayed #%d to %s: %s in %s", msg.id, msg.target,
+				msg.result.status, msg.result.duration.Round(time.Millisecond))
+			if preview := replayPreview(msg.result.body); preview != "" {
+				m.statusMsg += " - " + preview
+			}
+		}
+
+	case notifyWindowMsg:
+		if !m.notify || m.notifySuppressed == 0 {
+			m.notifyHeld = false
+			break
+		}
+		// Summarize the burst and keep holding while it lasts
+		cmds = append(cmds, sendNotification(fmt.Sprintf("%d more webhooks", m.notifySuppressed), "Latest: "+m.notifyLatest), scheduleNotifyWindow())
+		m.notifySuppressed = 0
+
+	case notifyFailedMsg:
+		m.notify = false
+		m.notifyHeld, m.notifySuppressed = false, 0
+		m.statusMsg = string(msg)
+		m.statusErr = true
+
+	case configSavedMsg:
+		if msg.err != nil {
+			m.statusMsg = fmt.Sprintf("Could not save %s: %v", msg.what, msg.err)
+			m.statusErr = true
+		}
+
+	case detailTunnelURLMsg:
+		if m.state == StateDetail && m.selectedIdx < len(m.webhooks) && m.webhooks[m.selectedIdx].ID == msg.id && msg.url != "" {
+			m.detailTunnelURL = msg.url
+			if m.jqResult == "" {
+				m.refreshDetail()
+			}
+		}
+
+	case clipboardMsg:
+		if msg.err != nil {
+			m.statusMsg = fmt.Sprintf("Copy failed: %v", msg.err)
+			m.statusErr = true
+		} else {
+			m.statusMsg = "Copied " + msg.what
+		}
+
+	case statsLoadedMsg:
+		m.stats = msg
+
+	case baselinesLoadedMsg:
+		m.baselines = msg
+
+	case cleanupLoadedMsg:
+		m.cleanup = msg
+		if m.cleanupIdx >= len(m.cleanup) {
+			m.cleanupIdx = 0
+		}
+
+	case markSetMsg:
+		mark := activityMark(msg)
+		m.mark = &mark
+		m.statusMsg = fmt.Sprintf("Mark set after #%d at %s; press ' to see what arrived since", mark.id, mark.at.Format(config.clockLayout()))
+
+	case sinceMarkLoadedMsg:
+		m.sinceMark = msg
+
+	case cleanupDoneMsg:
+		m.statusMsg = fmt.Sprintf("Deleted %d webhooks (%s), reclaimed %s", msg.count, strings.ToLower(msg.label), formatBytes(int(msg.reclaimed)))
+		if msg.count == 0 {
+			m.statusMsg = fmt.Sprintf("No webhooks to delete (%s)", strings.ToLower(msg.label))
+		}
+		m.currentPage = 0
+		cmds = append(cmds, loadCleanup(), loadWebhooksFromDB(0, m.pageSize, m.filter))
+
+	case startupPurgedMsg:
+		// Quiet unless something was removed; the startup load already has the rest
+		if msg.count > 0 {
+			m.statusMsg = fmt.Sprintf("Deleted %d webhooks (%s), reclaimed %s", msg.count, strings.ToLower(msg.label), formatBytes(int(msg.reclaimed)))
+			cmds = append(cmds, loadWebhooksFromDB(m.currentPage, m.pageSize, m.filter))
+		}
+
+	case historyLoadedMsg:
+		m.history = msg
+
+	case infoLoadedMsg:
+		m.info = msg
+
+	case exportDoneMsg:
+		m.statusMsg = fmt.Sprintf("Exported %d webhooks to %s", msg.count, msg.path)
+
+	case exportErrorMsg:
+		m.statusMsg = string(msg)
+		m.statusErr = true
+
+	case dbErrorMsg:
+		// Could show error in UI, for now just ignore
+
+	case spinner.TickMsg:
+		if !m.busy() {
+			// Let the tick chain lapse; Update restarts it when needed
+			m.spinning = false
+			break
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case clockTickMsg:
+		if !m.tunnelRunning && !m.serverRunning {
+			m.clocking = false
+			break
+		}
+		cmds = append(cmds, scheduleClockTick())
+	}
+
+	// Update ALL inputs - their internal Focus state controls which accepts keyboard input
+	if m.state == StateSetup {
+		var cmd tea.Cmd
+		m.portInput, cmd = m.portInput.Update(msg)
+		cmds = append(cmds, cmd)
+		m.subdomainInput, cmd = m.subdomainInput.Update(msg)
+		cmds = append(cmds, cmd)
+		m.timeoutInput, cmd = m.timeoutInput.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) View() string {
+	var b strings.Builder
+
+	// Title
+	title := titleStyle.Render("🪝 Webhook Listener TUI")
+	if m.flashText != "" {
+		title = alertFlashStyle.Width(max(m.width, 1)).Render("🔔 " + m.flashText)
+	}
+	if m.bell {
+		title = "\a" + title
+	}
+	b.WriteString(title + "\n\n")
+
+	if m.paletteMode {
+		b.WriteString(m.viewPalette())
+		return b.String()
+	}
+
+	switch m.state {
+	case StateSetup:
+		b.WriteString(m.viewSetup())
+	case StateRunning:
+		b.WriteString(m.viewRunning())
+	case StateDetail:
+		b.WriteString(m.viewDetail())
+	case StateStats:
+		b.WriteString(m.viewStats())
+	case StateHistory:
+		b.WriteString(m.viewHistory())
+	case StateInfo:
+		b.WriteString(m.viewInfo())
+	case StateCleanup:
+		b.WriteString(m.viewCleanup())
+	case StateSinceMark:
+		b.WriteString(m.viewSinceMark())
+	}
+
+	return b.String()
+}
+
+func (m Model) viewPalette() string {
+	var b strings.Builder
+
+	b.WriteString(headerStyle.Render("Commands") + "\n")
+	b.WriteString(m.paletteInput.View() + "\n\n")
+
+	matches := m.paletteMatches()
+	if len(matches) == 0 {
+		b.WriteString(infoStyle.Render("  No matching actions") + "\n")
+	}
+	for i, k := range matches {
+		line := fmt.Sprintf("%-8s %s", k.name(), k.desc)
+		if i == m.paletteIdx {
+			b.WriteString("  " + selectedStyle.Render("> "+line) + "\n")
+		} else {
+			b.WriteString("    " + line + "\n")
+		}
+	}
+
+	b.WriteString("\n" + helpStyle.Render("↑/↓: select • Enter: run • Esc: close"))
+
+	return b.String()
+}
+
+func (m Model) viewSetup() string {
+	var b strings.Builder
+
+	// Public IP section
+	b.WriteString(headerStyle.Render("Public IP Address") + "\n")
+	if m.fetchingIP {
+		b.WriteString(m.spinner.View() + " Fetching...\n")
+	} else {
+		b.WriteString(highlightStyle.Render(m.publicIP) + "\n")
+		b.WriteString(infoStyle.Render("(Use this for webhook authentication if needed)") + "\n")
+	}
+	b.WriteString("\n")
+
+	// Port input
+	b.WriteString(headerStyle.Render("Local Port") + "\n")
+	if m.focusedInput == 0 {
+		b.WriteString(selectedStyle.Render(m.portInput.View()) + "\n")
+	} else {
+		b.WriteString(m.portInput.View() + "\n")
+	}
+	b.WriteString(infoStyle.Render("Port for the local webhook server") + "\n\n")
+
+	// Subdomain input
+	b.WriteString(headerStyle.Render("Subdomain (optional)") + "\n")
+	if m.focusedInput == 1 {
+		b.WriteString(selectedStyle.Render(m.subdomainInput.View()) + "\n")
+	} else {
+		b.WriteString(m.subdomainInput.View() + "\n")
+	}
+	if _, ngrok := m.provider().(NgrokProvider); ngrok {
+		b.WriteString(infoStyle.Render("Reserved ngrok domain (e.g., my-app.ngrok.app)") + "\n\n")
+	} else {
+		b.WriteString(infoStyle.Render("Custom subdomain for localtunnel (e.g., my-app → my-app.loca.lt)") + "\n\n")
+	}
+
+	// Timeout input
+	b.WriteString(headerStyle.Render("Tunnel Timeout") + "\n")
+	if m.focusedInput == 2 {
+		b.WriteString(selectedStyle.Render(m.timeoutInput.View()) + "\n")
+	} else {
+		b.WriteString(m.timeoutInput.View() + "\n")
+	}
+	if m.statusErr && m.statusMsg != "" {
+		b.WriteString(errorStyle.Render(m.statusMsg) + "\n")
+	}
+	b.WriteString(infoStyle.Render("Auto-disconnect tunnel after e.g. 90s, 45m or 2h; a bare number is minutes (default: 30m)") + "\n\n")
+
+	// Provider selector
+	b.WriteString(headerStyle.Render("Tunnel Provider") + "\n")
+	var names []string
+	for i, p := range tunnelProviders {
+		if i == m.providerIdx {
+			names = append(names, highlightStyle.Render("● "+p.Name()))
+		} else {
+			names = append(names, infoStyle.Render("○ "+p.Name()))
+		}
+	}
+	providers := strings.Join(names, "  ")
+	if m.focusedInput == setupProviderField {
+		b.WriteString(selectedStyle.Render(providers) + "\n")
+	} else {
+		b.WriteString(providers + "\n")
+	}
+	b.WriteString(infoStyle.Render("←/→ to change; ngrok must be installed and authenticated") + "\n\n")
+
+	// Help
+	b.WriteString(helpStyle.Render("Tab: switch fields • Enter: start • q: quit"))
+
+	return b.String()
+}
+
+func (m Model) viewRunning() string {
+	var b strings.Builder
+
+	// Status section
+	b.WriteString(headerStyle.Render("Status") + "\n")
+
+	// Public IP
+	b.WriteString(fmt.Sprintf("  Public IP: %s\n", highlightStyle.Render(m.publicIP)))
+
+	// Server status
+	if m.serverError != "" {
+		b.WriteString(fmt.Sprintf("  Server: %s %s\n", errorStyle.Render("✗"), m.serverError))
+	} else if m.serverStopped {
+		b.WriteString(fmt.Sprintf("  Server: %s stopped with the tunnel - press r to reconnect both\n", infoStyle.Render("○")))
+	} else if m.serverRunning {
+		if config.ListenSocket != "" {
+			b.WriteString(fmt.Sprintf("  Server: %s on unix socket %s\n", successStyle.Render("●"), config.ListenSocket))
+		} else {
+			label := "port"
+			if len(m.ports) > 1 {
+				label = "ports"
+			}
+			b.WriteString(fmt.Sprintf("  Server: %s on %s %s\n", successStyle.Render("●"), label, strings.Join(m.ports, ", ")))
+		}
+	} else {
+		b.WriteString(fmt.Sprintf("  Server: %s Starting...\n", m.spinner.View()))
+	}
+	if m.apiServer != nil {
+		b.WriteString(fmt.Sprintf("  API: %s http://%s/api/webhooks\n", successStyle.Render("●"), m.apiServer.Addr))
+	}
+	if m.serverRunning {
+		counts := m.traffic.series(time.Now())
+		total := 0
+		for _, c := range counts {
+			total += c
+		}
+		b.WriteString(fmt.Sprintf("  Traffic: %s %s\n", renderSparkline(counts), infoStyle.Render(fmt.Sprintf("%d in the last %ds", total, len(counts)))))
+	}
+
+	// Tunnel status
+	if config.ListenSocket != "" {
+		b.WriteString(fmt.Sprintf("  Tunnel: %s\n", infoStyle.Render("disabled (listening on a unix socket)")))
+	} else if m.outsideHours {
+		_, next := config.ActiveHours.at(time.Now())
+		b.WriteString(fmt.Sprintf("  Tunnel: %s outside active hours, starts %s\n",
+			infoStyle.Render("● DOWN"), highlightStyle.Render(next.Format("Mon 15:04"))))
+	} else if m.reconnecting {
+		status := "reconnecting..."
+		if m.tunnelError != "" {
+			status += fmt.Sprintf(" (%s; retrying every %s at most)", m.tunnelError, m.reconnectDelay)
+		}
+		b.WriteString(fmt.Sprintf("  Tunnel: %s %s\n", m.spinner.View(), warningStyle.Render(status)))
+	} else if m.tunnelError != "" {
+		b.WriteString(fmt.Sprintf("  Tunnel: %s %s\n", errorStyle.Render("✗"), m.tunnelError))
+	} else if m.tunnelExpired {
+		b.WriteString(fmt.Sprintf("  Tunnel: %s (auto-shutdown after %v) - press 'r' to reconnect\n",
+			errorStyle.Render("● DISCONNECTED"), m.tunnelTimeout))
+		b.WriteString(fmt.Sprintf("  Last URL: %s\n", infoStyle.Render(m.tunnelURL)))
+	} else if m.tunnelRunning {
+		// Calculate time remaining
+		remaining := m.tunnelRemaining()
+		if remaining < 0 {
+			remaining = 0
+		}
+		minutes := int(remaining.Minutes())
+		seconds := int(remaining.Seconds()) % 60
+		remainingStr := fmt.Sprintf("%02d:%02d", minutes, seconds)
+
+		// Color the countdown based on time remaining
+		countdownStyle := successStyle
+		if remaining < 5*time.Minute {
+			countdownStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214")) // Orange/yellow
+		}
+		if remaining < 1*time.Minute {
+			countdownStyle = errorStyle // Red
+		}
+
+		// Only green once a request has made it through the tunnel
+		tunnelDot := successStyle.Render("●")
+		health := ""
+		switch {
+		case !m.tunnelVerified:
+			tunnelDot = warningStyle.Render("●")
+		case !m.tunnelReachable:
+			tunnelDot = errorStyle.Render("●")
+			health = errorStyle.Render(" unreachable")
+		default:
+			health = successStyle.Render(" reachable")
+		}
+		if health != "" && healthCheckInterval() > 0 {
+			health += infoStyle.Render(fmt.Sprintf(" (checked %s ago)", time.Since(m.lastHealthCheck).Truncate(time.Second)))
+		}
+
+		b.WriteString(fmt.Sprintf("  Tunnel: %s %s%s\n", tunnelDot, m.tunnelURL, health))
+		b.WriteString(fmt.Sprintf("  Webhook URL: %s\n", highlightStyle.Render(m.tunnelURL+"/webhook")))
+		b.WriteString(fmt.Sprintf("  Expires in: %s\n", countdownStyle.Render(remainingStr)))
+		if config.ActiveHours != nil {
+			if _, next := config.ActiveHours.at(time.Now()); !next.IsZero() {
+				b.WriteString(fmt.Sprintf("  Active hours: %s\n", infoStyle.Render("stops "+next.Format("Mon 15:04"))))
+			}
+		}
+	} else {
+		subdomainInfo := ""
+		if m.requestedSubdomain != "" {
+			subdomainInfo = fmt.Sprintf(" (subdomain: %s)", m.requestedSubdomain)
+		}
+		b.WriteString(fmt.Sprintf("  Tunnel: %s Starting %s...%s\n", m.spinner.View(), m.provider().Name(), subdomainInfo))
+	}
+	if !m.tunnelExpired && !m.tunnelVerified && config.ListenSocket == "" && !m.outsideHours {
+		b.WriteString(m.viewReadiness())
+	}
+	if idle := idleTimeout(); idle > 0 && m.serverRunning {
+		// Only show the idle countdown when close to the limit
+		if remaining := idle - time.Since(m.lastActivity); remaining < 2*time.Minute {
+			if remaining < 0 {
+				remaining = 0
+			}
+			b.WriteString(fmt.Sprintf("  Idle quit in: %s\n", warningStyle.Render(fmt.Sprintf("%02d:%02d", int(remaining.Minutes()), int(remaining.Seconds())%60))))
+		}
+	}
+	if n := m.droppedLive.Load(); n > 0 {
+		b.WriteString(fmt.Sprintf("  Dropped: %s\n", warningStyle.Render(fmt.Sprintf("%d events dropped from live view (still in DB, press 'l' to load)", n))))
+	}
+	if config.StrictPath != "" {
+		notFoundStr := fmt.Sprintf("%d not found (404)", m.notFound.Load())
+		if m.notFound.Load() > 0 {
+			notFoundStr = warningStyle.Render(notFoundStr)
+		}
+		b.WriteString(fmt.Sprintf("  Strict path: %s %s, %s\n",
+			highlightStyle.Render(config.StrictPath), infoStyle.Render(fmt.Sprintf("%d captured", m.captured.Load())), notFoundStr))
+	}
+	if config.ForwardTo != "" {
+		state := successStyle.Render("on")
+		if m.forwardPaused.Load() {
+			state = warningStyle.Render("paused")
+		}
+		b.WriteString(fmt.Sprintf("  Forwarding: %s → %s %s\n", state, config.ForwardTo, infoStyle.Render("(F to toggle)")))
+	}
+	if n := m.discarded.Load(); n > 0 {
+		b.WriteString(fmt.Sprintf("  Discarded: %s\n", infoStyle.Render(fmt.Sprintf("%d requests outside the capture filter", n))))
+	}
+	if m.oversizedHeaders > 0 {
+		b.WriteString(fmt.Sprintf("  Oversized headers: %s\n",
+			warningStyle.Render(fmt.Sprintf("%d requests above %s", m.oversizedHeaders, formatBytes(maxHeaderBytes())))))
+	}
+	if overrides := m.overrides.String(); overrides != "" {
+		b.WriteString(fmt.Sprintf("  Overrides: %s\n", warningStyle.Render(overrides)))
+	}
+	b.WriteString("\n")
+
+	// View mode indicator
+	viewModeStr := "List"
+	if m.viewMode == ViewModeTable {
+		viewModeStr = "Table"
+	}
+	// Show total count if loaded from DB, otherwise show current count
+	countStr := fmt.Sprintf("%d", len(m.webhooks))
+	if m.totalWebhooks > 0 {
+		countStr = fmt.Sprintf("%d total", m.totalWebhooks)
+	}
+	b.WriteString(headerStyle.Render(fmt.Sprintf("Webhooks (%s)", countStr)))
+
+	// Pagination and view mode info
+	pageInfo := ""
+	if m.totalPages > 1 {
+		pageInfo = fmt.Sprintf(" Page %d/%d |", m.currentPage+1, m.totalPages)
+	}
+	pathInfo := ""
+	if config.pathsAbbreviated() {
+		pathInfo = " [paths abbreviated]"
+	}
+	b.WriteString(infoStyle.Render(fmt.Sprintf("%s [%s]%s", pageInfo, viewModeStr, pathInfo)))
+	if m.filter.Method != "" {
+		b.WriteString(" " + highlightStyle.Render("["+m.filter.Method+" only]"))
+	}
+	if m.follow {
+		b.WriteString(" " + successStyle.Render("[following]"))
+	}
+	if m.decodePaths {
+		b.WriteString(" " + infoStyle.Render("[decoded paths]"))
+	}
+	if m.autoOpen {
+		b.WriteString(" " + successStyle.Render("[auto-open]"))
+	}
+	if m.notify {
+		b.WriteString(" " + successStyle.Render("[notify]"))
+	}
+	if m.mark != nil {
+		b.WriteString(" " + infoStyle.Render(fmt.Sprintf("[mark after #%d]", m.mark.id)))
+	}
+	b.WriteString("\n")
+	if len(config.ColorRules) > 0 {
+		// Legend for the row colors
+		legend := make([]string, len(config.ColorRules))
+		for i, rule := range config.ColorRules {
+			legend[i] = rule.style().Render("● " + rule.label())
+		}
+		b.WriteString(infoStyle.Render("Colors: ") + strings.Join(legend, "  ") + "\n")
+	}
+	if m.filter.active() {
+		b.WriteString(m.viewFilterSummary())
+	}
+
+	// The footer is built first so the rows can have whatever height is left
+	var footer strings.Builder
+	if m.statusMsg != "" {
+		if m.statusErr {
+			footer.WriteString("\n" + errorStyle.Render(m.statusMsg))
+		} else {
+			footer.WriteString("\n" + successStyle.Render(m.statusMsg))
+		}
+	}
+
+	// Help, filter input or delete confirmation
+	if m.confirmDeleteFilter {
+		footer.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Delete %d webhooks matching %s from the database? y/n", m.totalWebhooks, m.filter)))
+	} else if m.confirmDeleteID != 0 {
+		footer.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Delete #%d? y/n", m.confirmDeleteID)))
+	} else if m.confirmPurge {
+		footer.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Delete webhooks older than %d days and vacuum the database? y/n", config.RetainDays)))
+	} else if m.filterMode {
+		footer.WriteString("\n" + m.filterInput.View())
+	} else {
+		footer.WriteString("\n" + helpStyle.Render(keyHelp(StateRunning)))
+	}
+
+	// Lines left for rows: the title and blank line from View, the status
+	// block so far and the footer
+	free := m.height - 2 - renderedLines(b.String(), m.width) - renderedLines(footer.String(), m.width)
+	if len(m.webhooks) == 0 {
+		b.WriteString(infoStyle.Render("  Waiting for webhooks...") + "\n")
+	} else if m.viewMode == ViewModeTable {
+		b.WriteString(m.renderTableView(free))
+	} else {
+		b.WriteString(m.renderListView(free))
+	}
+
+	b.WriteString(footer.String())
+	return b.String()
+}
+
+// Lines each list item and the table header take up on screen
+const (
+	listItemLines    = 5 // two text lines, border and bottom margin
+	tableHeaderLines = 2 // titles and underline
+)
+
+// renderedLines counts terminal lines in s, including lines the terminal wraps
+func renderedLines(s string, width int) int {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return 0
+	}
+	n := 0
+	for _, line := range strings.Split(s, "\n") {
+		w := lipgloss.Width(line)
+		if width <= 0 || w <= width {
+			n++
+		} else {
+			n += (w + width - 1) / width
+		}
+	}
+	return n
+}
+
+// visibleRows is how many rows fit in free lines, or fallback before the
+// terminal size is known. At least one row is always shown.
+func (m Model) visibleRows(free, perRow, fallback int) int {
+	if m.height == 0 {
+		return fallback
+	}
+	return max(1, free/perRow)
+}
+
+// rowWindow returns the slice of a page to draw, scrolled so the selection
+// stays visible. A page holds at most page_size webhooks, so rows beyond the
+// screen are reached by scrolling and later webhooks by paging.
+func rowWindow(selected, total, rows int) (start, end int) {
+	if rows >= total {
+		return 0, total
+	}
+	if selected >= rows {
+		start = selected - rows + 1
+	}
+	return start, min(start+rows, total)
+}
+
+// viewFilterSummary renders the numbered active-filter line
+func (m Model) viewFilterSummary() string {
+	var parts []string
+	for i, c := range m.filter.clauses() {
+		parts = append(parts, fmt.Sprintf("%s %s", infoStyle.Render(fmt.Sprintf("[%d]", i+1)), highlightStyle.Render(c.label)))
+	}
+	return fmt.Sprintf("  Filters: %s  %s\n", strings.Join(parts, "  "), helpStyle.Render("1-9: clear one • Esc: clear all"))
+}
+
+// viewReadiness renders the startup checklist until the tunnel is verified end-to-end
+func (m Model) viewReadiness() string {
+	var b strings.Builder
+
+	step := func(done, failed bool, label string) {
+		mark := m.spinner.View()
+		if done {
+			mark = successStyle.Render("✓")
+		} else if failed {
+			mark = errorStyle.Render("✗")
+		}
+		b.WriteString(fmt.Sprintf("    %s %s\n", mark, label))
+	}
+
+	b.WriteString("  Readiness:\n")
+	step(m.serverRunning, m.serverError != "", "Server bound")
+	step(m.tunnelRunning, m.tunnelError != "", "Tunnel URL obtained")
+
+	verifyLabel := "Request through tunnel verified"
+	verifyFailed := false
+	if m.verifyError != "" {
+		verifyLabel += fmt.Sprintf(" (attempt %d/%d: %s)", m.verifyAttempts, maxVerifyAttempts, m.verifyError)
+		if !m.verifying && m.verifyAttempts >= maxVerifyAttempts {
+			verifyFailed = true
+			verifyLabel += " - press 'r' to retry"
+		}
+	}
+	step(m.tunnelVerified, verifyFailed, verifyLabel)
+
+	return b.String()
+}
+
+func (m Model) renderListView(free int) string {
+	var b strings.Builder
+
+	now := time.Now()
+	start, end := rowWindow(m.selectedIdx, len(m.webhooks), m.visibleRows(free, listItemLines, 10))
+	for i := start; i < end; i++ {
+		wh := m.webhooks[i]
+		age := relativeAge(wh.Timestamp, now)
+		preview := truncate(wh.Body, 50)
+		if preview == "" {
+			preview = "(empty body)"
+		}
+
+		path := m.shownPath(wh)
+		if rule := colorRuleFor(wh); rule != nil {
+			path = rule.style().Render(path)
+		}
+		item := fmt.Sprintf("#%d %s %s %s %s%s\n    %s",
+			wh.ID,
+			wh.Timestamp.Format(config.clockLayout()),
+			infoStyle.Render("("+age+")"),
+			methodStyle(wh.Method),
+			path,
+			retryBadge(wh)+headerSizeBadge(wh)+changeBadge(wh)+sequenceBadge(wh)+m.baselineBadge(wh),
+			infoStyle.Render(preview),
+		)
+		if wh.Processed {
+			// Dim handled webhooks
+			item = processedStyle.Render(fmt.Sprintf("#%d %s (%s) %s %s ✓\n    %s",
+				wh.ID,
+				wh.Timestamp.Format(config.clockLayout()),
+				age,
+				wh.Method,
+				m.shownPath(wh),
+				preview,
+			))
+		}
+
+		if i == m.selectedIdx {
+			b.WriteString(webhookSelectedStyle.Render(item) + "\n")
+		} else {
+			b.WriteString(webhookItemStyle.Render(item) + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+func (m Model) renderTableView(free int) string {
+	var b strings.Builder
+
+	// Table header
+	tableHeaderStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("39")).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		BorderForeground(lipgloss.Color("240"))
+
+	// Column widths
+	idW := 4
+	clockW := len(config.clockLayout()) + 1
+	ageW := 10
+	timeW := clockW + 1 + ageW
+	methodW := 8
+	pathW := 20
+	bodyW := 40
+
+	// Extra columns extracted from the JSON body, after the port when
+	// listening on several
+	jsonColW := 14
+	portW := 6
+	var jsonColHeader strings.Builder
+	if len(m.ports) > 1 {
+		jsonColHeader.WriteString(fmt.Sprintf("%-*s ", portW, "Port"))
+	}
+	for _, p := range config.JSONColumns {
+		jsonColHeader.WriteString(fmt.Sprintf("%-*s ", jsonColW, truncate(jsonPathHeader(p), jsonColW-3)))
+	}
+
+	header := fmt.Sprintf("%-*s %-*s %-*s %-*s %s%-*s",
+		idW, "ID",
+		timeW, fmt.Sprintf("%-*s %s", clockW, "Time", "Age"),
+		methodW, "Method",
+		pathW, "Path",
+		jsonColHeader.String(),
+		bodyW, "Body Preview",
+	)
+	b.WriteString(tableHeaderStyle.Render(header) + "\n")
+
+	// Table rows
+	now := time.Now()
+	start, end := rowWindow(m.selectedIdx, len(m.webhooks), m.visibleRows(free-tableHeaderLines, 1, 15))
+	for i := start; i < end; i++ {
+		wh := m.webhooks[i]
+		when := fmt.Sprintf("%-*s %s", clockW, wh.Timestamp.Format(config.clockLayout()), relativeAge(wh.Timestamp, now))
+		preview := truncate(wh.Body, bodyW-3)
+		if preview == "" {
+			preview = "(empty)"
+		}
+		if wh.RetryNum > 0 {
+			preview = truncate(fmt.Sprintf("↻%d %s", wh.RetryNum, preview), bodyW-3)
+		}
+		if wh.oversizedHeaders() {
+			preview = truncate("⚠hdr "+preview, bodyW-3)
+		}
+		if b, ok := m.baselines[wh.Path]; ok && b.webhookID != wh.ID && len(diffBaseline(b, wh)) > 0 {
+			preview = truncate("≠base "+preview, bodyW-3)
+		}
+		if wh.sequenceProblem() != "" {
+			preview = truncate("seq! "+preview, bodyW-3)
+		}
+		if wh.ChangeSummary == "unchanged" {
+			preview = truncate("= "+preview, bodyW-3)
+		} else if wh.ChangeSummary != "" {
+			preview = truncate("Δ "+preview, bodyW-3)
+		}
+		path := truncate(m.shownPath(wh), pathW-3)
+
+		var jsonCols strings.Builder
+		if len(m.ports) > 1 {
+			jsonCols.WriteString(fmt.Sprintf("%-*s ", portW, wh.Port))
+		}
+		for _, p := range config.JSONColumns {
+			jsonCols.WriteString(fmt.Sprintf("%-*s ", jsonColW, truncate(jsonPathString(wh.BodyJSON, p), jsonColW-3)))
+		}
+
+		row := fmt.Sprintf("%-*d %-*s %-*s %-*s %s%-*s",
+			idW, wh.ID,
+			timeW, when,
+			methodW, wh.Method,
+			pathW, path,
+			jsonCols.String(),
+			bodyW, preview,
+		)
+
+		if i == m.selectedIdx {
+			rowStyle := lipgloss.NewStyle().
+				Background(lipgloss.Color("236")).
+				Foreground(lipgloss.Color("212"))
+			b.WriteString(rowStyle.Render(row) + "\n")
+		} else if wh.Processed {
+			// Dim handled webhooks
+			b.WriteString(processedStyle.Render(row) + "\n")
+		} else {
+			// Color-code method in row
+			methodColored := methodStyle(wh.Method)
+			row = fmt.Sprintf("%-*d %-*s %s%s %-*s %s%-*s",
+				idW, wh.ID,
+				timeW, when,
+				methodColored, strings.Repeat(" ", methodW-len(wh.Method)),
+				pathW, path,
+				jsonCols.String(),
+				bodyW, preview,
+			)
+			if rule := colorRuleFor(wh); rule != nil {
+				// Everything but the method takes the rule's color
+				st := rule.style()
+				row = fmt.Sprintf("%s %s%s %s",
+					st.Render(fmt.Sprintf("%-*d %-*s", idW, wh.ID, timeW, when)),
+					methodColored, strings.Repeat(" ", methodW-len(wh.Method)),
+					st.Render(fmt.Sprintf("%-*s %s%-*s", pathW, path, jsonCols.String(), bodyW, preview)),
+				)
+			}
+			b.WriteString(row + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+func (m Model) buildDetailContent() string {
+	var b strings.Builder
+
+	if m.selectedIdx >= len(m.webhooks) {
+		return "No webhook selected"
+	}
+
+	wh := m.webhooks[m.selectedIdx]
+
+	// Metadata
+	b.WriteString(fmt.Sprintf("%s %s\n",
+		highlightStyle.Render("Method:"),
+		methodStyle(wh.Method),
+	))
+	if raw := wh.rawPath(); m.decodePaths && raw != wh.Path {
+		b.WriteString(fmt.Sprintf("%s %s %s\n", highlightStyle.Render("Path:"), wh.Path, infoStyle.Render("(raw: "+raw+")")))
+	} else {
+		b.WriteString(fmt.Sprintf("%s %s\n", highlightStyle.Render("Path:"), raw))
+	}
+	if wh.RawURI != "" {
+		b.WriteString(fmt.Sprintf("%s %s %s %s\n", highlightStyle.Render("Request:"), wh.Method, wh.RawURI, wh.proto()))
+	}
+	if wh.Port != "" && len(m.ports) > 1 {
+		b.WriteString(fmt.Sprintf("%s %s\n", highlightStyle.Render("Port:"), wh.Port))
+	}
+	b.WriteString(fmt.Sprintf("%s %s %s\n", highlightStyle.Render("Time:"),
+		wh.Timestamp.Local().Format("Mon Jan 2 2006 "+config.clockLayout()+" MST"),
+		infoStyle.Render("("+wh.Timestamp.Format(config.timestampLayout())+")")))
+	if wh.DeliveryID != "" {
+		b.WriteString(fmt.Sprintf("%s %s%s\n", highlightStyle.Render("Delivery:"), wh.DeliveryID, retryBadge(wh)))
+	}
+	if wh.ForwardError != "" {
+		b.WriteString(fmt.Sprintf("%s %s\n", highlightStyle.Render("Forwarded:"), errorStyle.Render("✗ "+wh.ForwardError)))
+	} else if wh.ForwardStatus != 0 {
+		b.WriteString(fmt.Sprintf("%s %d %s\n", highlightStyle.Render("Forwarded:"), wh.ForwardStatus, http.StatusText(wh.ForwardStatus)))
+	}
+	if wh.SignatureValid != nil {
+		signature := errorStyle.Render("✗ invalid")
+		if *wh.SignatureValid {
+			signature = successStyle.Render("✓ valid")
+		}
+		b.WriteString(fmt.Sprintf("%s %s %s\n", highlightStyle.Render("Signature:"), signature, infoStyle.Render("("+wh.SignatureProvider+")")))
+	}
+	if m.detailTunnelURL != "" {
+		b.WriteString(fmt.Sprintf("%s %s\n", highlightStyle.Render("Via:"), m.detailTunnelURL))
+	}
+	if wh.ResponseStatus != 0 {
+		rule := ""
+		if wh.ResponseRule != "" {
+			rule = infoStyle.Render(" (rule: " + wh.ResponseRule + ")")
+		}
+		b.WriteString(fmt.Sprintf("%s %d %s%s\n", highlightStyle.Render("Response:"), wh.ResponseStatus, http.StatusText(wh.ResponseStatus), rule))
+	}
+	if wh.ChangeSummary != "" {
+		b.WriteString(fmt.Sprintf("%s %s since the previous webhook on this path\n", highlightStyle.Render("Changes:"), wh.ChangeSummary))
+	}
+	if wh.Sequence != "" {
+		seq := wh.Sequence
+		if wh.SequenceExpected != 0 {
+			seq += infoStyle.Render(fmt.Sprintf(" (expected %d)", wh.SequenceExpected))
+		}
+		if problem := wh.sequenceProblem(); problem != "" {
+			seq += " " + warningStyle.Render(problem)
+		}
+		b.WriteString(fmt.Sprintf("%s %s\n", highlightStyle.Render("Sequence:"), seq))
+	}
+	b.WriteString("\n")
+
+	if query := wh.queryParams(); len(query) > 0 {
+		b.WriteString(headerStyle.Render("Query") + "\n")
+		for _, p := range query {
+			b.WriteString(fmt.Sprintf("  %s: %s\n", highlightStyle.Render(p.Name), p.Value))
+		}
+		b.WriteString("\n")
+	}
+
+	// Comparison with the expected payload for this path
+	if base, ok := m.baselines[wh.Path]; ok {
+		b.WriteString(headerStyle.Render("Baseline") + "\n")
+		if base.webhookID == wh.ID {
+			b.WriteString(infoStyle.Render("  This webhook is the baseline for "+wh.Path) + "\n")
+		} else if diffs := diffBaseline(base, wh); len(diffs) == 0 {
+			b.WriteString(successStyle.Render(fmt.Sprintf("  ✓ Matches baseline #%d", base.webhookID)) + "\n")
+		} else {
+			b.WriteString(errorStyle.Render(fmt.Sprintf("  ✗ %d differences from baseline #%d", len(diffs), base.webhookID)) + "\n")
+			for _, d := range diffs {
+				b.WriteString("    " + d + "\n")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	// Metadata attached externally
+	if len(wh.Metadata) > 0 {
+		b.WriteString(headerStyle.Render("Metadata") + "\n")
+		keys := make([]string, 0, len(wh.Metadata))
+		for k := range wh.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b.WriteString(fmt.Sprintf("  %s: %s\n", highlightStyle.Render(k), wh.Metadata[k]))
+		}
+		b.WriteString("\n")
+	}
+
+	// Headers
+	b.WriteString(headerStyle.Render("Headers"))
+	if wh.oversizedHeaders() {
+		b.WriteString(headerSizeBadge(wh))
+	}
+	b.WriteString("\n")
+	headerKeys := make([]string, 0, len(wh.Headers))
+	for k := range wh.Headers {
+		headerKeys = append(headerKeys, k)
+	}
+	sort.Strings(headerKeys)
+	for _, k := range headerKeys {
+		for _, v := range wh.headerValues(k) {
+			b.WriteString(fmt.Sprintf("  %s: %s\n", highlightStyle.Render(k), v))
+		}
+	}
+	if wh.HeadersDropped > 0 {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  %d headers not stored (drop_headers/header_allowlist)", wh.HeadersDropped)) + "\n")
+	}
+	b.WriteString("\n")
+
+	// Cookies parsed from the headers above
+	if sent, set := wh.cookies(); len(sent)+len(set) > 0 {
+		b.WriteString(headerStyle.Render("Cookies") + "\n")
+		for _, c := range sent {
+			b.WriteString(fmt.Sprintf("  %s = %s\n", highlightStyle.Render(c.Name), c.Value))
+		}
+		for _, c := range set {
+			attrs := ""
+			if _, rest, ok := strings.Cut(c.String(), "; "); ok {
+				attrs = " " + infoStyle.Render("("+rest+")")
+			}
+			b.WriteString(fmt.Sprintf("  %s %s = %s%s\n", infoStyle.Render("set"), highlightStyle.Render(c.Name), c.Value, attrs))
+		}
+		b.WriteString("\n")
+	}
+
+	// Body
+	b.WriteString(headerStyle.Render("Body"))
+	if wh.Decompressed != "" {
+		b.WriteString(" " + infoStyle.Render("(decompressed from "+wh.Decompressed+")"))
+	}
+	if note := wh.contentTypeNote(); note != "" {
+		b.WriteString(" " + infoStyle.Render("("+note+")"))
+	}
+	b.WriteString("\n")
+	binary := wh.binaryBody()
+	if binary {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("Binary body, %s - press x to toggle the hex dump", formatBytes(len(wh.Body)))) + "\n")
+	}
+	if binary && m.hexMode {
+		width := m.viewport.Width - m.detailGutterWidth - 3
+		if config.MaxContentWidth > 0 && config.MaxContentWidth < width {
+			width = config.MaxContentWidth
+		}
+		b.WriteString(hexDump([]byte(wh.Body), width))
+	} else if len(wh.Parts) > 0 {
+		b.WriteString(renderMultipartParts(wh.Parts))
+	} else if elems, ok := wh.BodyJSON.([]interface{}); ok && m.arrayMode && !wh.forcedText() {
+		b.WriteString(m.renderArrayPage(elems))
+	} else if wh.BodyJSON != nil && !wh.forcedText() {
+		if elems, ok := wh.BodyJSON.([]interface{}); ok && len(elems) > arrayPageSize {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("Array of %d elements - press a to page through them", len(elems))) + "\n")
+		}
+		formatted, err := m.formatBodyJSON(wh)
+		if err != nil {
+			b.WriteString(bodyStyle.Render(wh.Body) + "\n")
+		} else if m.compactJSON {
+			b.WriteString(bodyStyle.Render(formatted) + "\n")
+		} else {
+			b.WriteString(highlightJSON(formatted) + "\n")
+		}
+	} else if fields, ok := wh.formFields(); ok {
+		b.WriteString(renderFormFields(fields))
+	} else if wh.XML {
+		if indented, err := indentXML(wh.Body); err == nil {
+			b.WriteString(bodyStyle.Render(indented) + "\n")
+		} else {
+			b.WriteString(bodyStyle.Render(wh.Body) + "\n")
+		}
+	} else if wh.Body != "" {
+		b.WriteString(bodyStyle.Render(wh.Body) + "\n")
+	} else {
+		b.WriteString(infoStyle.Render("(empty)") + "\n")
+	}
+
+	return b.String()
+}
+
+// binaryBody reports whether the body is neither JSON nor readable text:
+// invalid UTF-8 or control characters other than whitespace
+func (wh WebhookPayload) binaryBody() bool {
+	if wh.BodyJSON != nil || len(wh.Parts) > 0 {
+		return false
+	}
+	if !utf8.ValidString(wh.Body) {
+		return true
+	}
+	for _, r := range wh.Body {
+		if (r < 0x20 && r != '\t' && r != '\n' && r != '\r') || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// hexDump formats data like hexdump -C: offset, hex bytes and printable
+// ASCII. Rows hold 16 bytes, or 8 when that would not fit in width, so the
+// dump never wraps in the viewport.
+func hexDump(data []byte, width int) string {
+	perRow := 16
+	if width < 78 {
+		perRow = 8
+	}
+
+	var b strings.Builder
+	for off := 0; off < len(data); off += perRow {
+		row := data[off:min(off+perRow, len(data))]
+		b.WriteString(infoStyle.Render(fmt.Sprintf("%08x", off)) + "  ")
+		for i := 0; i < perRow; i++ {
+			if i < len(row) {
+				fmt.Fprintf(&b, "%02x ", row[i])
+			} else {
+				b.WriteString("   ")
+			}
+			if i == 7 && perRow > 8 {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(" |")
+		for _, c := range row {
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			b.WriteByte(c)
+		}
+		b.WriteString("|\n")
+	}
+	fmt.Fprintf(&b, "%s\n", infoStyle.Render(fmt.Sprintf("%08x", len(data))))
+	return b.String()
+}
+
+// arrayPageSize is how many array elements the detail view shows per page
+const arrayPageSize = 10
+
+// renderArrayPage shows one page of a top-level JSON array, each element
+// under its index
+func (m Model) renderArrayPage(elems []interface{}) string {
+	var b strings.Builder
+
+	pages := max(1, (len(elems)+arrayPageSize-1)/arrayPageSize)
+	page := min(m.arrayPage, pages-1)
+	start := page * arrayPageSize
+	end := min(start+arrayPageSize, len(elems))
+
+	b.WriteString(infoStyle.Render(fmt.Sprintf("Array of %d elements - page %d/%d ([/]: prev/next page, a: whole body)", len(elems), page+1, pages)) + "\n")
+	for i := start; i < end; i++ {
+		b.WriteString(highlightStyle.Render(fmt.Sprintf("[%d]", i)) + "\n")
+		var out []byte
+		var err error
+		if m.compactJSON {
+			out, err = json.Marshal(elems[i])
+		} else {
+			out, err = json.MarshalIndent(elems[i], "", "  ")
+		}
+		if err != nil {
+			b.WriteString(errorStyle.Render(err.Error()) + "\n")
+		} else if m.compactJSON {
+			b.WriteString(bodyStyle.Render(string(out)) + "\n")
+		} else {
+			b.WriteString(highlightJSON(string(out)) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// formatBodyJSON renders a JSON body pretty-printed or minified, depending on
+// the current detail mode. Copy actions use it so they match what is shown.
+func (m Model) formatBodyJSON(wh WebhookPayload) (string, error) {
+	var out []byte
+	var err error
+	if m.compactJSON {
+		out, err = json.Marshal(wh.BodyJSON)
+	} else {
+		out, err = json.MarshalIndent(wh.BodyJSON, "", "  ")
+	}
+	return string(out), err
+}
+
+// renderMultipartParts shows text fields inline and file parts as name/size/type
+func renderMultipartParts(parts []MultipartPart) string {
+	var b strings.Builder
+
+	b.WriteString(infoStyle.Render(fmt.Sprintf("multipart/form-data, %d parts", len(parts))) + "\n")
+	for _, p := range parts {
+		if p.Filename != "" {
+			contentType := p.ContentType
+			if contentType == "" {
+				contentType = "unknown type"
+			}
+			b.WriteString(fmt.Sprintf("  %s: %s %s\n",
+				highlightStyle.Render(p.Name),
+				p.Filename,
+				infoStyle.Render(fmt.Sprintf("(file, %s, %s)", formatBytes(p.Size), contentType)),
+			))
+		} else {
+			b.WriteString(fmt.Sprintf("  %s: %s\n", highlightStyle.Render(p.Name), bodyStyle.Render(p.Value)))
+		}
+	}
+
+	return b.String()
+}
+
+// renderFormFields lists form fields sorted by name, one line per value
+func renderFormFields(fields url.Values) string {
+	var b strings.Builder
+
+	names := make([]string, 0, len(fields))
+	nameW := 0
+	for name := range fields {
+		names = append(names, name)
+		nameW = max(nameW, len(name))
+	}
+	sort.Strings(names)
+
+	b.WriteString(infoStyle.Render(fmt.Sprintf("application/x-www-form-urlencoded, %d fields", len(names))) + "\n")
+	for _, name := range names {
+		for _, value := range fields[name] {
+			pad := strings.Repeat(" ", nameW-len(name))
+			b.WriteString(fmt.Sprintf("  %s%s  %s\n", highlightStyle.Render(name), pad, bodyStyle.Render(value)))
+		}
+	}
+
+	return b.String()
+}
+
+func (m Model) viewStats() string {
+	var b strings.Builder
+
+	title := "Stats"
+	if m.filter.active() {
+		title += fmt.Sprintf(" (filter: %s)", m.filter)
+	}
+	b.WriteString(headerStyle.Render(title) + "\n\n")
+
+	if m.stats == nil {
+		b.WriteString(m.spinner.View() + " Loading...\n")
+	} else {
+		b.WriteString(renderStatsSummary(m.stats) + "\n")
+		b.WriteString(highlightStyle.Render(m.stats.metric+" distribution") + "\n")
+		b.WriteString(renderHistogram(m.stats.histogram, 40))
+	}
+
+	b.WriteString("\n" + helpStyle.Render("Tab: switch metric • S/Esc: back • q: quit"))
+
+	return b.String()
+}
+
+// renderStatsSummary shows the totals and the method and status breakdowns
+func renderStatsSummary(stats *statsData) string {
+	var b strings.Builder
+
+	b.WriteString(highlightStyle.Render("Summary") + "\n")
+	b.WriteString(fmt.Sprintf("  Webhooks:     %d\n", stats.total))
+	if stats.total > 0 {
+		b.WriteString(fmt.Sprintf("  Earliest:     %s\n", formatStoredTime(stats.earliest)))
+		b.WriteString(fmt.Sprintf("  Latest:       %s\n", formatStoredTime(stats.latest)))
+	}
+	minutes := time.Since(sessionStart).Minutes()
+	b.WriteString(fmt.Sprintf("  This session: %d %s\n", stats.session,
+		infoStyle.Render(fmt.Sprintf("(%.1f/min over %s)", float64(stats.session)/max(minutes, 1), time.Since(sessionStart).Round(time.Second)))))
+
+	breakdown := func(label string, buckets []histogramBucket, name func(string) string) {
+		if len(buckets) == 0 {
+			return
+		}
+		parts := make([]string, len(buckets))
+		for i, bucket := range buckets {
+			parts[i] = fmt.Sprintf("%s %d", name(bucket.label), bucket.count)
+		}
+		b.WriteString(fmt.Sprintf("  %-13s %s\n", label+":", strings.Join(parts, "  ")))
+	}
+	breakdown("Methods", stats.methods, func(s string) string { return s })
+	breakdown("Statuses", stats.statuses, func(s string) string {
+		if s == "0" {
+			return "unrecorded"
+		}
+		return s
+	})
+
+	return b.String()
+}
+
+// formatStoredTime renders a timestamp column value for display
+func formatStoredTime(value string) string {
+	if t, ok := parseStoredTime(value); ok {
+		return t.Format(config.timestampLayout())
+	}
+	return value
+}
+
+func (m Model) viewCleanup() string {
+	var b strings.Builder
+
+	b.WriteString(headerStyle.Render("Cleanup") + "\n\n")
+
+	if m.cleanup == nil {
+		b.WriteString(m.spinner.View() + " Loading...\n")
+	} else {
+		b.WriteString(fmt.Sprintf("    %-10s  %8s  %10s\n", "Age", "Webhooks", "Size"))
+		for i, bucket := range m.cleanup {
+			line := fmt.Sprintf("%-10s  %8d  %10s", bucket.label, bucket.count, formatBytes(int(bucket.size)))
+			if i == m.cleanupIdx {
+				b.WriteString("  " + selectedStyle.Render("> "+line) + "\n")
+			} else {
+				b.WriteString("    " + line + "\n")
+			}
+		}
+	}
+
+	if m.statusMsg != "" {
+		if m.statusErr {
+			b.WriteString("\n" + errorStyle.Render(m.statusMsg) + "\n")
+		} else {
+			b.WriteString("\n" + successStyle.Render(m.statusMsg) + "\n")
+		}
+	}
+
+	if m.confirmCleanup {
+		bucket := m.cleanup[m.cleanupIdx]
+		b.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Delete %d webhooks (%s) and vacuum the database? y/n", bucket.count, strings.ToLower(bucket.label))))
+	} else {
+		b.WriteString("\n" + helpStyle.Render("j/k: select • d: delete bucket • X/Esc: back • q: quit"))
+	}
+
+	return b.String()
+}
+
+func (m Model) viewSinceMark() string {
+	var b strings.Builder
+
+	b.WriteString(headerStyle.Render(fmt.Sprintf("Since mark (after #%d, %s)", m.mark.id, m.mark.at.Format(config.clockLayout()))) + "\n\n")
+
+	if m.sinceMark == nil {
+		b.WriteString(m.spinner.View() + " Loading...\n")
+	} else if m.sinceMark.total == 0 {
+		b.WriteString(infoStyle.Render("  Nothing received since the mark") + "\n")
+	} else {
+		s := m.sinceMark
+		elapsed := time.Since(m.mark.at).Round(time.Second)
+		b.WriteString(fmt.Sprintf("  %s webhooks in %s\n\n", highlightStyle.Render(fmt.Sprintf("%d", s.total)), elapsed))
+
+		b.WriteString(highlightStyle.Render("  By method") + "\n")
+		for _, c := range s.byMethod {
+			b.WriteString(fmt.Sprintf("    %-8s %5d\n", c.key, c.count))
+		}
+		b.WriteString("\n" + highlightStyle.Render("  By path") + "\n")
+		for _, c := range s.byPath {
+			b.WriteString(fmt.Sprintf("    %-40s %5d\n", truncate(c.key, 40), c.count))
+		}
+
+		b.WriteString("\n" + highlightStyle.Render("  Latest") + "\n")
+		for _, wh := range s.recent {
+			b.WriteString(fmt.Sprintf("    #%-5d %s %s %s\n", wh.ID, wh.Timestamp.Format(config.clockLayout()), methodStyle(wh.Method), m.shownPath(wh)))
+		}
+		if s.total > len(s.recent) {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("    … and %d more", s.total-len(s.recent))) + "\n")
+		}
+	}
+
+	b.WriteString("\n" + helpStyle.Render("'/Esc: back • q: quit"))
+
+	return b.String()
+}
+
+func (m Model) viewHistory() string {
+	var b strings.Builder
+
+	b.WriteString(headerStyle.Render("Tunnel History") + "\n\n")
+
+	if config.DisableHistory {
+		b.WriteString(infoStyle.Render("  History is disabled (disable_history in config)") + "\n")
+	} else if len(m.history) == 0 {
+		b.WriteString(infoStyle.Render("  No tunnel sessions recorded yet") + "\n")
+	} else {
+		b.WriteString(fmt.Sprintf("  %-19s  %-40s  %-15s  %-5s  %s\n", "Started", "Tunnel URL", "Public IP", "Port", "Webhooks"))
+		for _, e := range m.history {
+			b.WriteString(fmt.Sprintf("  %-19s  %-40s  %-15s  %-5s  %d\n",
+				e.startedAt.Local().Format("2006-01-02 15:04:05"),
+				truncate(e.tunnelURL, 37),
+				e.publicIP,
+				e.port,
+				e.webhooks,
+			))
+		}
+	}
+
+	b.WriteString("\n" + helpStyle.Render("H/Esc: back • q: quit"))
+
+	return b.String()
+}
+
+func (m Model) viewInfo() string {
+	var b strings.Builder
+
+	b.WriteString(headerStyle.Render("Info") + "\n\n")
+
+	if m.info == nil {
+		b.WriteString(m.spinner.View() + " Loading...\n")
+	} else {
+		field := func(name, value string) {
+			b.WriteString(fmt.Sprintf("  %s %s\n", highlightStyle.Render(fmt.Sprintf("%-12s", name+":")), value))
+		}
+		orNone := func(s string) string {
+			if s == "" {
+				return infoStyle.Render("(none)")
+			}
+			return s
+		}
+
+		field("Database", m.info.path)
+		field("Size", formatBytes(int(m.info.fileSize)))
+		rows := strconv.Itoa(m.info.rows)
+		if config.MaxRows > 0 {
+			rows += fmt.Sprintf(" / %d (oldest evicted beyond the cap)", config.MaxRows)
+		}
+		field("Rows", rows)
+		field("Oldest", orNone(m.info.oldest))
+		field("Newest", orNone(m.info.newest))
+		b.WriteString("\n")
+
+		// Effective settings: what was entered on the setup screen plus the config file
+		b.WriteString(highlightStyle.Render("Settings") + "\n")
+		field("Port", orNone(strings.Join(m.ports, ", ")))
+		field("Subdomain", orNone(m.requestedSubdomain))
+		field("Timeout", m.tunnelTimeout.String())
+		field("Page size", strconv.Itoa(m.pageSize))
+		if runID != "" {
+			field("Run ID", fmt.Sprintf("%s %s", runID, infoStyle.Render(fmt.Sprintf("(%d webhooks, filter with run:%s)", m.info.runRows, runID))))
+		} else {
+			field("Run ID", infoStyle.Render("(none, set WEBHOOK_TUI_RUN_ID)"))
+		}
+		b.WriteString("\n")
+
+		b.WriteString(highlightStyle.Render("Config") + " " + infoStyle.Render(configPath) + "\n")
+		cfg, _ := json.MarshalIndent(config.redacted(), "  ", "  ")
+		b.WriteString("  " + highlightJSON(string(cfg)) + "\n")
+	}
+
+	b.WriteString("\n" + helpStyle.Render("i/Esc: back • q: quit"))
+
+	return b.String()
+}
+
+// renderHistogram draws one horizontal bar per bucket, scaled to maxWidth
+func renderHistogram(buckets []histogramBucket, maxWidth int) string {
+	var b strings.Builder
+
+	maxCount, labelW := 0, 0
+	for _, bucket := range buckets {
+		if bucket.count > maxCount {
+			maxCount = bucket.count
+		}
+		if len(bucket.label) > labelW {
+			labelW = len(bucket.label)
+		}
+	}
+
+	barStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	for _, bucket := range buckets {
+		width := 0
+		if maxCount > 0 {
+			width = bucket.count * maxWidth / maxCount
+		}
+		if width == 0 && bucket.count > 0 {
+			width = 1
+		}
+		b.WriteString(fmt.Sprintf("  %*s │%s %s\n",
+			labelW, bucket.label,
+			barStyle.Render(strings.Repeat("█", width)),
+			infoStyle.Render(strconv.Itoa(bucket.count)),
+		))
+	}
+
+	return b.String()
+}
+
+func (m Model) viewDetail() string {
+	var b strings.Builder
+
+	if m.selectedIdx >= len(m.webhooks) {
+		return "No webhook selected"
+	}
+
+	wh := m.webhooks[m.selectedIdx]
+
+	// Header
+	if m.jqResult != "" {
+		b.WriteString(headerStyle.Render(fmt.Sprintf("Webhook #%d | %s", wh.ID, m.resultTitle)) + "\n\n")
+	} else {
+		b.WriteString(headerStyle.Render(fmt.Sprintf("Webhook #%d Details", wh.ID)) + "\n\n")
+	}
+
+	// Viewport with scrollable content
+	b.WriteString(m.viewport.View() + "\n\n")
+
+	// Scroll indicator with optional search info
+	scrollPercent := int(m.viewport.ScrollPercent() * 100)
+	var scrollInfo string
+	if m.searchQuery != "" && len(m.searchMatches) > 0 {
+		scrollInfo = infoStyle.Render(fmt.Sprintf("─── %d%% ─── match %d/%d for '%s' ───",
+			scrollPercent, m.searchMatchIdx+1, len(m.searchMatches), m.searchQuery))
+	} else if m.searchQuery != "" {
+		scrollInfo = infoStyle.Render(fmt.Sprintf("─── %d%% ─── no matches for '%s' ───",
+			scrollPercent, m.searchQuery))
+	} else {
+		scrollInfo = infoStyle.Render(fmt.Sprintf("─── %d%% ───", scrollPercent))
+	}
+	b.WriteString(scrollInfo + "\n")
+
+	// Help, search input or a transient status message
+	if m.searchMode {
+		b.WriteString(m.searchInput.View() + "  ")
+		if m.searchErr != "" {
+			b.WriteString(errorStyle.Render(m.searchErr))
+		} else {
+			b.WriteString(helpStyle.Render("Enter: search • ctrl+r: regex on/off • Esc: cancel"))
+		}
+	} else if m.jqMode {
+		b.WriteString(m.jqInput.View())
+	} else if m.pathMode {
+		b.WriteString(m.pathInput.View())
+	} else if m.replayMode {
+		b.WriteString(m.replayInput.View())
+	} else if m.statusMsg != "" && m.statusErr {
+		b.WriteString(errorStyle.Render(m.statusMsg))
+	} else if m.statusMsg != "" {
+		b.WriteString(successStyle.Render(m.statusMsg))
+	} else if m.jqResult != "" {
+		b.WriteString(helpStyle.Render("↑/↓/j/k: scroll • /: search • :: new path • |: new jq filter • Esc: back to details"))
+	} else {
+		b.WriteString(helpStyle.Render(keyHelp(StateDetail)))
+	}
+
+	return b.String()
+}
+
+// refreshDetail rebuilds the detail content for the selected webhook, e.g. after
+// entering the detail view or toggling a rendering option
+func (m *Model) refreshDetail() {
+	m.jqResult = ""
+	content := m.buildDetailContent()
+	// Calculate line number gutter width (4 digits + " │ " = 7 chars)
+	m.detailGutterWidth = 4
+	gutterTotal := m.detailGutterWidth + 3 // " │ "
+	// Wrap content to viewport width minus gutter (or the configured max width)
+	m.detailContent = layoutDetailContent(content, m.viewport.Width-gutterTotal)
+	// Keep search matches in sync with the new content
+	m.findSearchMatches()
+	if m.searchMatchIdx >= len(m.searchMatches) {
+		m.searchMatchIdx = 0
+	}
+	// Set viewport with line numbers
+	m.updateDetailViewport()
+}
+
+// showJQResult replaces the detail content with jq output until Esc
+func (m *Model) showJQResult(output string) {
+	if output == "" {
+		output = "(no output)"
+	}
+	m.showResult("jq "+m.jqExpr, output, highlightJSON(output))
+}
+
+// showPathResult replaces the detail content with the value at a path, or
+// the reason there is none, until Esc
+func (m *Model) showPathResult(body interface{}, expr string) {
+	val, err := lookupJSONPath(body, expr)
+	if err != nil {
+		m.showResult(expr, err.Error(), errorStyle.Render("✗ "+err.Error()))
+		return
+	}
+	out, err := json.MarshalIndent(val, "", "  ")
+	if err != nil {
+		m.showResult(expr, err.Error(), errorStyle.Render("✗ "+err.Error()))
+		return
+	}
+	m.showResult(expr, string(out), highlightJSON(string(out)))
+}
+
+// showResult puts rendered in place of the detail content under title
+func (m *Model) showResult(title, output, rendered string) {
+	m.resultTitle = title
+	m.jqResult = output
+	m.detailContent = layoutDetailContent(rendered, m.viewport.Width-m.detailGutterWidth-3)
+	m.findSearchMatches()
+	m.searchMatchIdx = 0
+	m.updateDetailViewport()
+	m.viewport.GotoTop()
+}
+
+// replayPreviewBytes is how much of a replay response body the footer shows
+const replayPreviewBytes = 120
+
+// replayToTarget re-sends a webhook from the detail view
+func replayToTarget(wh WebhookPayload, target string) tea.Cmd {
+	return func() tea.Msg {
+		res, err := replayWebhook(wh, target)
+		return detailReplayMsg{id: wh.ID, target: target, result: res, err: err}
+	}
+}
+
+// replayPreview is the start of a response body flattened onto one line
+func replayPreview(body []byte) string {
+	return truncate(strings.Join(strings.Fields(string(body)), " "), replayPreviewBytes)
+}
+
+// runJQ pipes a body through jq. jq is run directly, not through a shell.
+func runJQ(body, expr string) tea.Cmd {
+	return func() tea.Msg {
+		if _, err := exec.LookPath("jq"); err != nil {
+			return jqResultMsg{err: fmt.Errorf("jq not found in PATH - install jq to filter bodies")}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, "jq", "-M", expr)
+		cmd.Stdin = strings.NewReader(body)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			// The first line carries the error; jq adds a summary line after it
+			if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+				return jqResultMsg{err: fmt.Errorf("%s", msg)}
 			}
+			return jqResultMsg{err: fmt.Errorf("jq: %v", err)}
+		}
+		return jqResultMsg{output: strings.TrimRight(stdout.String(), "\n")}
+	}
+}
+
+// findSearchMatches finds all lines containing the search query
+func (m *Model) findSearchMatches() {
+	m.searchMatches = nil
+	if m.searchRe == nil || m.detailContent == "" {
+		return
+	}
+
+	lines := strings.Split(m.detailContent, "\n")
+	for i, line := range lines {
+		// Strip ANSI codes for searching
+		if m.searchRe.MatchString(stripANSI(line)) {
+			m.searchMatches = append(m.searchMatches, i)
+		}
+	}
+}
+
+// compileSearch turns a detail search query into a pattern: plain queries
+// match case-insensitively as literal text
+func compileSearch(query string, regex bool) (*regexp.Regexp, error) {
+	if !regex {
+		return regexp.Compile("(?i)" + regexp.QuoteMeta(query))
+	}
+	re, err := regexp.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %v", query, err)
+	}
+	return re, nil
+}
+
+// searchPrompt marks the search input when queries are regular expressions
+func searchPrompt(regex bool) string {
+	if regex {
+		return "re/"
+	}
+	return "/"
+}
+
+// updateDetailViewport updates the viewport content with line numbers and search highlighting
+func (m *Model) updateDetailViewport() {
+	if m.detailContent == "" {
+		return
+	}
 
-		case "l":
-			if m.state == StateRunning {
-				cmds = append(cmds, loadWebhooksFromDB(0))
-			}
+	var content string
+	if m.searchRe != nil && m.searchQuery != "" {
+		content = highlightSearchMatches(m.detailContent, m.searchRe)
+	} else {
+		content = m.detailContent
+	}
 
-		case "r":
-			// Reconnect tunnel
-			if m.state == StateRunning && (m.tunnelExpired || !m.tunnelRunning) {
-				m.tunnelExpired = false
-				m.tunnelError = ""
-				cmds = append(cmds, startTunnel(m.requestedPort, m.requestedSubdomain))
-			}
+	numbered := addLineNumbers(content, m.detailGutterWidth)
+	m.viewport.SetContent(numbered)
+}
 
-		case "n":
-			if m.state == StateDetail && len(m.searchMatches) > 0 {
-				// Next search match
-				m.searchMatchIdx = (m.searchMatchIdx + 1) % len(m.searchMatches)
-				m.viewport.SetYOffset(m.searchMatches[m.searchMatchIdx])
-				cmds = append(cmds, tea.ClearScreen)
-			} else if m.state == StateRunning && m.currentPage < m.totalPages-1 {
-				m.currentPage++
-				cmds = append(cmds, loadWebhooksFromDB(m.currentPage))
-			}
+// highlightSearchMatches highlights everything re matches in the content
+func highlightSearchMatches(content string, re *regexp.Regexp) string {
+	lines := strings.Split(content, "\n")
+	var result strings.Builder
 
-		case "right":
-			if m.state == StateRunning && m.currentPage < m.totalPages-1 {
-				m.currentPage++
-				cmds = append(cmds, loadWebhooksFromDB(m.currentPage))
-			}
+	for i, line := range lines {
+		result.WriteString(highlightLineMatches(line, re))
+		if i < len(lines)-1 {
+			result.WriteString("\n")
+		}
+	}
 
-		case "p", "left":
-			if m.state == StateRunning && m.currentPage > 0 {
-				m.currentPage--
-				cmds = append(cmds, loadWebhooksFromDB(m.currentPage))
-			}
+	return result.String()
+}
 
-		case "pgup":
-			if m.state == StateDetail {
-				m.viewport.HalfViewUp()
-				cmds = append(cmds, tea.ClearScreen)
-			}
+// highlightLineMatches highlights matches in a single line. Matches are found
+// in the text without ANSI codes, then mapped back onto the styled line.
+func highlightLineMatches(line string, re *regexp.Regexp) string {
+	locs := re.FindAllStringIndex(stripANSI(line), -1)
+	if len(locs) == 0 {
+		return line
+	}
 
-		case "pgdown":
-			if m.state == StateDetail {
-				m.viewport.HalfViewDown()
-				cmds = append(cmds, tea.ClearScreen)
-			}
+	var result strings.Builder
+	prev := 0
+	for _, loc := range locs {
+		if loc[0] == loc[1] {
+			// Empty matches have nothing to highlight
+			continue
+		}
+		start, end := findActualIndex(line, loc[0]), findActualIndex(line, loc[1])
+		result.WriteString(line[prev:start])
+		result.WriteString(searchHighlightStyle.Render(stripANSI(line[start:end])))
+		prev = end
+	}
+	result.WriteString(line[prev:])
 
-		case "ctrl+f":
-			if m.state == StateDetail {
-				m.viewport.ViewDown()
-				cmds = append(cmds, tea.ClearScreen)
-			}
+	return result.String()
+}
 
-		case "ctrl+b":
-			if m.state == StateDetail {
-				m.viewport.ViewUp()
-				cmds = append(cmds, tea.ClearScreen)
-			}
+// findActualIndex finds the actual byte index in a string with ANSI codes
+// given a visual character index (ignoring ANSI codes)
+func findActualIndex(s string, visualIdx int) int {
+	ansiPattern := regexp.MustCompile(`\x1b\[[0-9;]*m`)
 
-		case "ctrl+d":
-			if m.state == StateDetail {
-				m.viewport.HalfViewDown()
-				cmds = append(cmds, tea.ClearScreen)
-			}
+	actualIdx := 0
+	visualCount := 0
 
-		case "ctrl+u":
-			if m.state == StateDetail {
-				m.viewport.HalfViewUp()
-				cmds = append(cmds, tea.ClearScreen)
-			}
+	for actualIdx < len(s) && visualCount < visualIdx {
+		// Check if we're at the start of an ANSI sequence
+		if loc := ansiPattern.FindStringIndex(s[actualIdx:]); loc != nil && loc[0] == 0 {
+			// Skip the ANSI sequence
+			actualIdx += loc[1]
+		} else {
+			// Regular character
+			actualIdx++
+			visualCount++
+		}
+	}
 
-		case "G":
-			if m.state == StateDetail {
-				m.viewport.GotoBottom()
-				cmds = append(cmds, tea.ClearScreen)
-			} else if m.state == StateRunning && len(m.webhooks) > 0 {
-				m.selectedIdx = len(m.webhooks) - 1
-			}
+	return actualIdx
+}
 
-		case "g":
-			if m.state == StateDetail {
-				m.viewport.GotoTop()
-				cmds = append(cmds, tea.ClearScreen)
-			} else if m.state == StateRunning && len(m.webhooks) > 0 {
-				m.selectedIdx = 0
+// stripANSI removes ANSI escape codes from a string
+func stripANSI(s string) string {
+	ansiPattern := regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// wrapContent wraps text to the specified width while preserving ANSI escape codes
+func wrapContent(content string, width int) string {
+	// wrap.String is ANSI-aware and will hard-wrap at the specified width
+	return wrap.String(content, width)
+}
+
+// layoutDetailContent wraps detail content to the available width, or to
+// config.MaxContentWidth when that is narrower, optionally centering it
+func layoutDetailContent(content string, available int) string {
+	width := available
+	if config.MaxContentWidth > 0 && config.MaxContentWidth < available {
+		width = config.MaxContentWidth
+	}
+
+	wrapped := wrapContent(content, width)
+	if !config.CenterContent || width >= available {
+		return wrapped
+	}
+
+	pad := strings.Repeat(" ", (available-width)/2)
+	lines := strings.Split(wrapped, "\n")
+	for i, line := range lines {
+		lines[i] = pad + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// looksLikeXML reports whether a body is a well-formed XML document
+func looksLikeXML(body []byte) bool {
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("<")) {
+		return false
+	}
+	_, err := indentXML(string(body))
+	return err == nil
+}
+
+// indentXML re-serializes XML one element per line. Elements holding only
+// text stay on one line; prefixes are kept as written rather than resolved.
+func indentXML(body string) (string, error) {
+	dec := xml.NewDecoder(strings.NewReader(body))
+	var lines []string
+	var open []string // names of the enclosing elements
+	inline := false   // the last line is a start tag that may still take text and its end tag
+	elements := 0
+
+	indent := func() string { return strings.Repeat("  ", len(open)) }
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var tag strings.Builder
+			tag.WriteString("<" + xmlName(t.Name))
+			for _, attr := range t.Attr {
+				tag.WriteString(" " + xmlName(attr.Name) + `="` + xmlEscape(attr.Value) + `"`)
+			}
+			tag.WriteString(">")
+			lines = append(lines, indent()+tag.String())
+			open = append(open, xmlName(t.Name))
+			inline = true
+			elements++
+		case xml.EndElement:
+			if len(open) == 0 || open[len(open)-1] != xmlName(t.Name) {
+				return "", fmt.Errorf("unexpected </%s>", xmlName(t.Name))
+			}
+			open = open[:len(open)-1]
+			if inline {
+				lines[len(lines)-1] += "</" + xmlName(t.Name) + ">"
+			} else {
+				lines = append(lines, indent()+"</"+xmlName(t.Name)+">")
+			}
+			inline = false
+		case xml.CharData:
+			text := strings.TrimSpace(string(t))
+			if text == "" {
+				continue
 			}
+			if inline {
+				lines[len(lines)-1] += xmlEscape(text)
+			} else {
+				lines = append(lines, indent()+xmlEscape(text))
+			}
+		case xml.Comment:
+			lines = append(lines, indent()+"<!--"+string(t)+"-->")
+			inline = false
+		case xml.ProcInst:
+			lines = append(lines, indent()+"<?"+t.Target+" "+string(t.Inst)+"?>")
+		case xml.Directive:
+			lines = append(lines, indent()+"<!"+string(t)+">")
 		}
+	}
+	if elements == 0 || len(open) > 0 {
+		return "", fmt.Errorf("incomplete XML document")
+	}
+	return strings.Join(lines, "\n"), nil
+}
 
-	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
-		// Viewport height accounts for: header+blank (2) + blanks after viewport (2) + scroll indicator (1) + help (1) = 6 lines
-		if !m.viewportReady {
-			m.viewport = viewport.New(msg.Width-4, msg.Height-6)
-			m.viewport.HighPerformanceRendering = false
-			m.viewportReady = true
-		} else {
-			m.viewport.Width = msg.Width - 4
-			m.viewport.Height = msg.Height - 6
+func xmlName(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+func xmlEscape(s string) string {
+	return xmlEscaper.Replace(s)
+}
+
+// highlightJSON applies syntax highlighting to JSON text
+func highlightJSON(jsonStr string) string {
+	var result strings.Builder
+	lines := strings.Split(jsonStr, "\n")
+
+	for i, line := range lines {
+		result.WriteString(highlightJSONLine(line))
+		if i < len(lines)-1 {
+			result.WriteString("\n")
 		}
+	}
 
-	case publicIPMsg:
-		m.publicIP = string(msg)
-		m.fetchingIP = false
+	return result.String()
+}
 
-	case publicIPErrMsg:
-		m.publicIP = "Unable to fetch"
-		m.fetchingIP = false
+// highlightJSONLine highlights a single line of JSON
+func highlightJSONLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	indent := line[:len(line)-len(trimmed)]
+
+	// Empty or whitespace-only line
+	if trimmed == "" {
+		return line
+	}
+
+	// Bracket-only lines
+	switch strings.TrimSuffix(trimmed, ",") {
+	case "{", "}", "[", "]", "{}", "[]":
+		bracket := strings.TrimSuffix(trimmed, ",")
+		comma := ""
+		if strings.HasSuffix(trimmed, ",") {
+			comma = ","
+		}
+		return indent + jsonBracketStyle.Render(bracket) + comma
+	}
+
+	// Check if line has a key (a quoted string followed by a colon)
+	if strings.HasPrefix(trimmed, "\"") {
+		keyEnd := jsonStringEnd(trimmed)
+		if keyEnd > 0 && strings.HasPrefix(trimmed[keyEnd:], ":") {
+			// This is a key: value line
+			key := trimmed[:keyEnd]
+			rest := trimmed[keyEnd+1:] // skip :
+
+			var result strings.Builder
+			result.WriteString(indent)
+			result.WriteString(jsonKeyStyle.Render(key))
+			result.WriteString(": ")
+
+			value := strings.TrimSpace(rest)
+			result.WriteString(highlightJSONValue(value))
+			return result.String()
+		}
+	}
+
+	// Array element (string, number, etc.)
+	return indent + highlightJSONValue(trimmed)
+}
+
+// jsonStringEnd returns the index just past the closing quote of the JSON
+// string s starts with, skipping escaped quotes, or -1 if it isn't closed
+func jsonStringEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// jsonNumberPattern matches a JSON number literal
+var jsonNumberPattern = regexp.MustCompile(`^-?\d+\.?\d*([eE][+-]?\d+)?$`)
+
+// highlightJSONValue highlights a JSON value
+func highlightJSONValue(value string) string {
+	// Remove trailing comma for analysis
+	hasComma := strings.HasSuffix(value, ",")
+	cleanValue := strings.TrimSuffix(value, ",")
+	comma := ""
+	if hasComma {
+		comma = ","
+	}
+
+	// String value
+	if strings.HasPrefix(cleanValue, "\"") && strings.HasSuffix(cleanValue, "\"") {
+		return jsonStringStyle.Render(cleanValue) + comma
+	}
+
+	// Boolean
+	if cleanValue == "true" || cleanValue == "false" {
+		return jsonBoolStyle.Render(cleanValue) + comma
+	}
+
+	// Null
+	if cleanValue == "null" {
+		return jsonNullStyle.Render(cleanValue) + comma
+	}
+
+	// Number (int or float)
+	if jsonNumberPattern.MatchString(cleanValue) {
+		return jsonNumberStyle.Render(cleanValue) + comma
+	}
+
+	// Array/object start, or an empty one
+	if cleanValue == "[" || cleanValue == "{" || cleanValue == "[]" || cleanValue == "{}" {
+		return jsonBracketStyle.Render(cleanValue) + comma
+	}
+
+	// Default - return as-is
+	return value
+}
 
-	case tunnelStartedMsg:
-		m.tunnelURL = msg.url
-		m.tunnelCmd = msg.cmd
-		m.tunnelRunning = true
-		m.tunnelExpired = false
-		m.tunnelStartTime = time.Now()
-		// Schedule auto-shutdown
-		cmds = append(cmds, scheduleTunnelExpiration(m.tunnelTimeout))
+// lookupJSONPath walks a decoded JSON value along a path such as
+// "data.items[0].id", "$.data.items.0.id" or ".items[-1]" (negative indexes
+// count from the end). The error names the step that failed.
+func lookupJSONPath(v interface{}, path string) (interface{}, error) {
+	if v == nil {
+		return nil, fmt.Errorf("body is not JSON")
+	}
+	if strings.Count(path, "[") != strings.Count(path, "]") {
+		return nil, fmt.Errorf("unbalanced brackets in %q", path)
+	}
+	segs := jsonPathSegments(path)
+	if len(segs) == 1 && segs[0] == "" {
+		return v, nil
+	}
 
-	case tunnelExpiredMsg:
-		if m.tunnelRunning && !m.tunnelExpired {
-			// Kill the tunnel
-			if m.tunnelCmd != nil && m.tunnelCmd.Process != nil {
-				syscall.Kill(-m.tunnelCmd.Process.Pid, syscall.SIGTERM)
-				m.tunnelCmd.Process.Kill()
+	cur := v
+	at := "."
+	for _, seg := range segs {
+		if seg == "" {
+			return nil, fmt.Errorf("empty key after %s", at)
+		}
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			next, ok := node[seg]
+			if !ok {
+				return nil, fmt.Errorf("%s has no key %q", at, seg)
 			}
-			m.tunnelRunning = false
-			m.tunnelExpired = true
+			cur = next
+			at = strings.TrimSuffix(at, ".") + "." + seg
+		case []interface{}:
+			idx, err := strconv.Atoi(seg)
+			if err != nil {
+				return nil, fmt.Errorf("%s is an array, %q is not an index", at, seg)
+			}
+			if idx < 0 {
+				idx += len(node)
+			}
+			if idx < 0 || idx >= len(node) {
+				return nil, fmt.Errorf("index %s out of range, %s has %d elements", seg, at, len(node))
+			}
+			cur = node[idx]
+			at = strings.TrimSuffix(at, ".") + fmt.Sprintf("[%d]", idx)
+		default:
+			return nil, fmt.Errorf("%s is %s, not an object or array", at, jsonKind(cur))
 		}
+	}
+	return cur, nil
+}
 
-	case tunnelErrorMsg:
-		m.tunnelError = string(msg)
-
-	case serverStartedMsg:
-		m.serverRunning = true
-		cmds = append(cmds, waitForWebhook(m.webhookChan))
+// jsonKind names the type of a decoded JSON value for messages
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case float64, json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	}
+	return "null"
+}
 
-	case webhookReceivedMsg:
-		m.webhooksMu.Lock()
-		m.webhooks = append([]WebhookPayload{WebhookPayload(msg)}, m.webhooks...)
-		m.webhooksMu.Unlock()
-		cmds = append(cmds, waitForWebhook(m.webhookChan))
+// jsonPathSegments splits a path as lookupJSONPath reads it
+func jsonPathSegments(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+	return strings.Split(strings.TrimPrefix(path, "."), ".")
+}
 
-	case webhooksLoadedMsg:
-		m.webhooksMu.Lock()
-		m.webhooks = msg.webhooks
-		m.totalWebhooks = msg.totalCount
-		m.currentPage = msg.currentPage
-		m.totalPages = (msg.totalCount + pageSize - 1) / pageSize
-		if m.totalPages == 0 {
-			m.totalPages = 1
+// validateJSONPath rejects paths that select nothing or have empty segments
+func validateJSONPath(path string) error {
+	if strings.Trim(path, "$.") == "" {
+		return fmt.Errorf("empty path")
+	}
+	if strings.Count(path, "[") != strings.Count(path, "]") {
+		return fmt.Errorf("unbalanced brackets")
+	}
+	for _, seg := range jsonPathSegments(path) {
+		if seg == "" {
+			return fmt.Errorf("empty segment")
 		}
-		m.selectedIdx = 0
-		m.webhooksMu.Unlock()
+	}
+	return nil
+}
 
-	case dbErrorMsg:
-		// Could show error in UI, for now just ignore
+// jsonPathString extracts a value for display, returning "" for missing paths
+func jsonPathString(v interface{}, path string) string {
+	val, err := lookupJSONPath(v, path)
+	if err != nil || val == nil {
+		return ""
+	}
+	if str, isStr := val.(string); isStr {
+		return str
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
 
-	case spinner.TickMsg:
-		var cmd tea.Cmd
-		m.spinner, cmd = m.spinner.Update(msg)
-		cmds = append(cmds, cmd)
+// jsonPathHeader derives a column header from the last segment of a JSON path
+func jsonPathHeader(path string) string {
+	path = strings.TrimSuffix(path, "]")
+	if idx := strings.LastIndexAny(path, ".["); idx != -1 && idx < len(path)-1 {
+		return path[idx+1:]
 	}
+	return path
+}
 
-	// Update ALL inputs - their internal Focus state controls which accepts keyboard input
-	if m.state == StateSetup {
-		var cmd tea.Cmd
-		m.portInput, cmd = m.portInput.Update(msg)
-		cmds = append(cmds, cmd)
-		m.subdomainInput, cmd = m.subdomainInput.Update(msg)
-		cmds = append(cmds, cmd)
-		m.timeoutInput, cmd = m.timeoutInput.Update(msg)
-		cmds = append(cmds, cmd)
+// addLineNumbers adds vim-style line numbers to content
+func addLineNumbers(content string, gutterWidth int) string {
+	lines := strings.Split(content, "\n")
+	var result strings.Builder
+
+	for i, line := range lines {
+		lineNum := fmt.Sprintf("%*d", gutterWidth, i+1)
+		result.WriteString(lineNumberStyle.Render(lineNum))
+		result.WriteString(" │ ")
+		result.WriteString(line)
+		if i < len(lines)-1 {
+			result.WriteString("\n")
+		}
 	}
 
-	return m, tea.Batch(cmds...)
+	return result.String()
 }
 
-func (m Model) View() string {
-	var b strings.Builder
+// keyBinding is a key from the Update switch, with how it appears in the
+// help line and the command palette and the screens where it does something.
+// An entry without help stays out of the help line; one without desc stays
+// out of the palette.
+type keyBinding struct {
+	key    string // key replayed when the entry is run from the palette
+	label  string // keys shown to the user, when not key itself (e.g. "j/k")
+	help   string // short text for the help line
+	desc   string // palette description
+	states []State
+}
 
-	// Title
-	title := titleStyle.Render("🪝 Webhook Listener TUI")
-	b.WriteString(title + "\n\n")
+// keyBindings is the one list of keys behind the help lines and the command
+// palette. Add an entry here when adding a key to update; running an entry
+// from the palette replays its key. Help lines keep the order of this list.
+var keyBindings = []keyBinding{
+	{key: "j", label: "j/k", help: "select", states: []State{StateRunning}},
+	{key: "up", label: "↑/↓/j/k", help: "scroll", states: []State{StateDetail}},
+	{key: "n", label: "n/p", help: "page", states: []State{StateRunning}},
+	{key: "n", desc: "Next page", states: []State{StateRunning}},
+	{key: "p", desc: "Previous page", states: []State{StateRunning}},
+	{key: "+", desc: "Show more webhooks per page", states: []State{StateRunning}},
+	{key: "-", desc: "Show fewer webhooks per page", states: []State{StateRunning}},
+	{key: "enter", label: "Enter", help: "details", desc: "View webhook details", states: []State{StateRunning}},
+	{key: " ", label: "Space", help: "processed", desc: "Toggle processed flag", states: []State{StateRunning}},
+	{key: "u", help: "hide processed", desc: "Hide/show processed webhooks", states: []State{StateRunning}},
+	{key: "/", help: "filter", desc: "Filter webhooks", states: []State{StateRunning}},
+	{key: "M", help: "method", desc: "Cycle the method filter (all, GET, POST, PUT, DELETE, PATCH)", states: []State{StateRunning}},
+	{key: "/", help: "search", desc: "Search in details", states: []State{StateDetail}},
+	{key: "n", label: "n/N", help: "next/prev", states: []State{StateDetail}},
+	{key: "g", label: "g/G", help: "top/bottom", states: []State{StateDetail}},
+	{key: "d", help: "delete", desc: "Delete the selected webhook", states: []State{StateRunning}},
+	{key: "D", help: "delete filtered", desc: "Delete webhooks matching the filter", states: []State{StateRunning}},
+	{key: "B", help: "export bundle", desc: "Export the filtered webhooks as a bundle", states: []State{StateRunning}},
+	{key: "e", help: "export all", desc: "Export all webhooks as NDJSON", states: []State{StateRunning}},
+	{key: "h", help: "export HAR", desc: "Export all webhooks as HAR", states: []State{StateRunning}},
+	{key: "S", help: "stats", desc: "Show stats", states: []State{StateRunning}},
+	{key: "H", help: "history", desc: "Show tunnel history", states: []State{StateRunning}},
+	{key: "i", help: "info", desc: "Show info and settings", states: []State{StateRunning}},
+	{key: "X", help: "cleanup", desc: "Open the cleanup screen", states: []State{StateRunning}},
+	{key: "m", help: "mark", desc: "Set a mark at the newest webhook", states: []State{StateRunning}},
+	{key: "'", help: "since mark", desc: "Show what arrived since the mark", states: []State{StateRunning}},
+	{key: "J", help: "compact JSON", desc: "Toggle pretty/compact JSON", states: []State{StateDetail}},
+	{key: "a", help: "array pages", desc: "Page through a JSON array body", states: []State{StateDetail}},
+	{key: "x", help: "hex dump", desc: "Toggle a hex dump of the body", states: []State{StateDetail}},
+	{key: ":", help: "path", desc: "Show the value at a JSON path", states: []State{StateDetail}},
+	{key: "|", help: "jq", desc: "Filter the body through jq", states: []State{StateDetail}},
+	{key: "E", help: "baseline", desc: "Mark/unmark as the baseline for its path", states: []State{StateRunning, StateDetail}},
+	{key: "b", help: "copy body", desc: "Copy the body", states: []State{StateDetail}},
+	{key: "M", help: "copy markdown", desc: "Copy webhook as markdown", states: []State{StateDetail}},
+	{key: "y", help: "copy curl", desc: "Copy webhook as a curl command", states: []State{StateDetail}},
+	{key: "R", help: "replay", desc: "Replay webhook to a URL", states: []State{StateDetail}},
+	{key: "o", help: "toggle 500", desc: "Toggle a 500 response for the path", states: []State{StateRunning, StateDetail}},
+	{key: "f", help: "follow", desc: "Toggle follow mode", states: []State{StateRunning}},
+	{key: "t", help: "view", desc: "Toggle table/list view", states: []State{StateRunning}},
+	{key: "r", help: "reconnect", desc: "Reconnect tunnel", states: []State{StateRunning}},
+	{key: "l", help: "load DB", desc: "Load webhooks from the database", states: []State{StateRunning}},
+	{key: "c", help: "clear", desc: "Clear the current view", states: []State{StateRunning}},
+	{key: "%", desc: "Toggle raw/decoded paths", states: []State{StateRunning, StateDetail}},
+	{key: "ctrl+n", desc: "Toggle desktop notifications", states: []State{StateRunning, StateDetail}},
+	{key: "A", desc: "Toggle auto-open of new webhooks", states: []State{StateRunning, StateDetail}},
+	{key: "F", desc: "Pause/resume forwarding to forward_to", states: []State{StateRunning, StateDetail}},
+	{key: "P", desc: "Purge webhooks older than retain_days", states: []State{StateRunning}},
+	{key: "esc", label: "Esc", help: "back", desc: "Back", states: []State{StateDetail, StateStats, StateHistory, StateInfo, StateCleanup, StateSinceMark}},
+	{key: "q", help: "quit", desc: "Quit", states: []State{StateRunning, StateDetail, StateStats, StateHistory, StateInfo, StateCleanup, StateSinceMark}},
+}
 
-	switch m.state {
-	case StateSetup:
-		b.WriteString(m.viewSetup())
-	case StateRunning:
-		b.WriteString(m.viewRunning())
-	case StateDetail:
-		b.WriteString(m.viewDetail())
+// name is how the binding's keys are shown to the user
+func (k keyBinding) name() string {
+	if k.label != "" {
+		return k.label
 	}
+	return k.key
+}
 
-	return b.String()
+// availableIn reports whether the binding does something on screen s
+func (k keyBinding) availableIn(s State) bool {
+	for _, state := range k.states {
+		if state == s {
+			return true
+		}
+	}
+	return false
 }
 
-func (m Model) viewSetup() string {
-	var b strings.Builder
+// keyMsg builds the key press that triggers the binding
+func (k keyBinding) keyMsg() tea.KeyMsg {
+	switch k.key {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "ctrl+n":
+		return tea.KeyMsg{Type: tea.KeyCtrlN}
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k.key)}
+}
 
-	// Public IP section
-	b.WriteString(headerStyle.Render("Public IP Address") + "\n")
-	if m.fetchingIP {
-		b.WriteString(m.spinner.View() + " Fetching...\n")
-	} else {
-		b.WriteString(highlightStyle.Render(m.publicIP) + "\n")
-		b.WriteString(infoStyle.Render("(Use this for webhook authentication if needed)") + "\n")
+// keyHelp is the help line for screen s
+func keyHelp(s State) string {
+	var parts []string
+	for _, k := range keyBindings {
+		if k.help != "" && k.availableIn(s) {
+			parts = append(parts, k.name()+": "+k.help)
+		}
 	}
-	b.WriteString("\n")
+	return strings.Join(parts, " • ")
+}
 
-	// Port input
-	b.WriteString(headerStyle.Render("Local Port") + "\n")
-	if m.focusedInput == 0 {
-		b.WriteString(selectedStyle.Render(m.portInput.View()) + "\n")
-	} else {
-		b.WriteString(m.portInput.View() + "\n")
+// paletteMatches lists the actions for the current screen whose key or
+// description contains the typed letters in order
+func (m Model) paletteMatches() []keyBinding {
+	query := strings.ToLower(strings.ReplaceAll(m.paletteInput.Value(), " ", ""))
+	var matches []keyBinding
+	for _, k := range keyBindings {
+		if k.desc != "" && k.availableIn(m.state) && fuzzyMatch(strings.ToLower(k.name()+" "+k.desc), query) {
+			matches = append(matches, k)
+		}
 	}
-	b.WriteString(infoStyle.Render("Port for the local webhook server") + "\n\n")
+	return matches
+}
 
-	// Subdomain input
-	b.WriteString(headerStyle.Render("Subdomain (optional)") + "\n")
-	if m.focusedInput == 1 {
-		b.WriteString(selectedStyle.Render(m.subdomainInput.View()) + "\n")
-	} else {
-		b.WriteString(m.subdomainInput.View() + "\n")
+// fuzzyMatch reports whether the runes of query appear in s in order
+func fuzzyMatch(s, query string) bool {
+	for _, r := range query {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+utf8.RuneLen(r):]
 	}
-	b.WriteString(infoStyle.Render("Custom subdomain for localtunnel (e.g., my-app → my-app.loca.lt)") + "\n\n")
+	return true
+}
 
-	// Timeout input
-	b.WriteString(headerStyle.Render("Tunnel Timeout (minutes)") + "\n")
-	if m.focusedInput == 2 {
-		b.WriteString(selectedStyle.Render(m.timeoutInput.View()) + "\n")
-	} else {
-		b.WriteString(m.timeoutInput.View() + "\n")
+// provider is the tunnel provider picked on the setup screen
+func (m Model) provider() TunnelProvider {
+	return tunnelProviders[m.providerIdx]
+}
+
+// openDetail shows the selected webhook in the detail view, returning the
+// lookup of the tunnel URL it arrived through
+func (m *Model) openDetail() tea.Cmd {
+	m.state = StateDetail
+	m.arrayPage = 0
+	m.detailTunnelURL = ""
+	// Clear any previous search, or highlight what the list filter matched
+	m.searchQuery = ""
+	m.searchRe = nil
+	if query, regex := m.filter.highlight(); query != "" {
+		m.searchQuery = query
+		m.searchRe, _ = compileSearch(query, regex)
+	}
+	m.searchMatches = nil
+	m.searchMatchIdx = 0
+	// Set viewport content for the selected webhook
+	m.refreshDetail()
+	m.viewport.GotoTop()
+	if len(m.searchMatches) > 0 {
+		m.viewport.SetYOffset(m.searchMatches[0])
+	}
+	wh := m.webhooks[m.selectedIdx]
+	return loadTunnelURLAt(wh.ID, wh.Timestamp)
+}
+
+// stopFollowing turns follow off when the list selection is moved by hand,
+// so new arrivals don't pull the cursor away
+func (m *Model) stopFollowing() {
+	if m.follow && m.state == StateRunning {
+		m.follow = false
+		m.statusMsg = "Follow off (f to resume)"
 	}
-	b.WriteString(infoStyle.Render("Auto-disconnect tunnel after this many minutes (default: 30)") + "\n\n")
+}
 
-	// Help
-	b.WriteString(helpStyle.Render("Tab: switch fields • Enter: start • q: quit"))
+// autoOpenQuietPeriod is how long after a keypress auto-open holds off
+const autoOpenQuietPeriod = 3 * time.Second
 
-	return b.String()
+// typing reports whether an input or the command palette has the keyboard
+func (m Model) typing() bool {
+	return m.filterMode || m.searchMode || m.jqMode || m.pathMode || m.replayMode || m.paletteMode
 }
 
-func (m Model) viewRunning() string {
-	var b strings.Builder
+// idleInList reports whether the list is showing with nothing in progress,
+// so a new webhook may take over the screen
+func (m Model) idleInList() bool {
+	return m.state == StateRunning && !m.typing() && !m.confirmDeleteFilter && m.confirmDeleteID == 0 && !m.confirmPurge &&
+		time.Since(m.lastKeyAt) >= autoOpenQuietPeriod
+}
 
-	// Status section
-	b.WriteString(headerStyle.Render("Status") + "\n")
+// selectionAfterReload returns the index of the previously selected webhook
+// in the reloaded list, or 0 if it is no longer on the page
+func selectionAfterReload(webhooks []WebhookPayload, selectedID int) int {
+	if selectedID == 0 {
+		return 0
+	}
+	for i, wh := range webhooks {
+		if wh.ID == selectedID {
+			return i
+		}
+	}
+	return 0
+}
 
-	// Public IP
-	b.WriteString(fmt.Sprintf("  Public IP: %s\n", highlightStyle.Render(m.publicIP)))
+// insertByID inserts a webhook keeping the list ordered newest (highest id)
+// first. Concurrent handlers can deliver to the channel out of order.
+func insertByID(webhooks []WebhookPayload, wh WebhookPayload) []WebhookPayload {
+	idx := sort.Search(len(webhooks), func(i int) bool {
+		return webhooks[i].ID < wh.ID
+	})
+	webhooks = append(webhooks, WebhookPayload{})
+	copy(webhooks[idx+1:], webhooks[idx:])
+	webhooks[idx] = wh
+	return webhooks
+}
 
-	// Server status
-	if m.serverRunning {
-		b.WriteString(fmt.Sprintf("  Server: %s on port %s\n", successStyle.Render("●"), m.requestedPort))
-	} else {
-		b.WriteString(fmt.Sprintf("  Server: %s Starting...\n", m.spinner.View()))
+// headerSizeBadge warns about a header set larger than the configured threshold
+func headerSizeBadge(wh WebhookPayload) string {
+	if !wh.oversizedHeaders() {
+		return ""
 	}
+	return " " + warningStyle.Render(fmt.Sprintf("[⚠ headers %s > %s]", formatBytes(wh.HeaderBytes), formatBytes(maxHeaderBytes())))
+}
 
-	// Tunnel status
-	if m.tunnelError != "" {
-		b.WriteString(fmt.Sprintf("  Tunnel: %s %s\n", errorStyle.Render("✗"), m.tunnelError))
-	} else if m.tunnelExpired {
-		b.WriteString(fmt.Sprintf("  Tunnel: %s (auto-shutdown after %v) - press 'r' to reconnect\n",
-			errorStyle.Render("● DISCONNECTED"), m.tunnelTimeout))
-		b.WriteString(fmt.Sprintf("  Last URL: %s\n", infoStyle.Render(m.tunnelURL)))
-	} else if m.tunnelRunning {
-		// Calculate time remaining
-		elapsed := time.Since(m.tunnelStartTime)
-		remaining := m.tunnelTimeout - elapsed
-		if remaining < 0 {
-			remaining = 0
-		}
-		minutes := int(remaining.Minutes())
-		seconds := int(remaining.Seconds()) % 60
-		remainingStr := fmt.Sprintf("%02d:%02d", minutes, seconds)
+// baselineBadge shows whether a webhook matches the expected payload for its path
+func (m Model) baselineBadge(wh WebhookPayload) string {
+	b, ok := m.baselines[wh.Path]
+	switch {
+	case !ok:
+		return ""
+	case b.webhookID == wh.ID:
+		return " " + infoStyle.Render("[baseline]")
+	}
+	if diffs := diffBaseline(b, wh); len(diffs) > 0 {
+		return " " + errorStyle.Render(fmt.Sprintf("[≠ baseline: %d]", len(diffs)))
+	}
+	return " " + successStyle.Render("[= baseline]")
+}
 
-		// Color the countdown based on time remaining
-		countdownStyle := successStyle
-		if remaining < 5*time.Minute {
-			countdownStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214")) // Orange/yellow
-		}
-		if remaining < 1*time.Minute {
-			countdownStyle = errorStyle // Red
-		}
+// changeBadge says whether the body differs from the previous webhook on the path
+func changeBadge(wh WebhookPayload) string {
+	switch wh.ChangeSummary {
+	case "":
+		return ""
+	case "unchanged":
+		return " " + infoStyle.Render("[unchanged]")
+	default:
+		return " " + warningStyle.Render("["+wh.ChangeSummary+"]")
+	}
+}
 
-		b.WriteString(fmt.Sprintf("  Tunnel: %s %s\n", successStyle.Render("●"), m.tunnelURL))
-		b.WriteString(fmt.Sprintf("  Webhook URL: %s\n", highlightStyle.Render(m.tunnelURL+"/webhook")))
-		b.WriteString(fmt.Sprintf("  Expires in: %s\n", countdownStyle.Render(remainingStr)))
-	} else {
-		subdomainInfo := ""
-		if m.requestedSubdomain != "" {
-			subdomainInfo = fmt.Sprintf(" (subdomain: %s)", m.requestedSubdomain)
-		}
-		b.WriteString(fmt.Sprintf("  Tunnel: %s Starting localtunnel...%s\n", m.spinner.View(), subdomainInfo))
+func sequenceBadge(wh WebhookPayload) string {
+	if problem := wh.sequenceProblem(); problem != "" {
+		return " " + warningStyle.Render("[seq "+problem+"]")
 	}
-	b.WriteString("\n")
+	return ""
+}
 
-	// View mode indicator
-	viewModeStr := "List"
-	if m.viewMode == ViewModeTable {
-		viewModeStr = "Table"
+// retryBadge marks a webhook that repeats an earlier delivery id
+func retryBadge(wh WebhookPayload) string {
+	if wh.RetryNum == 0 {
+		return ""
 	}
-	// Show total count if loaded from DB, otherwise show current count
-	countStr := fmt.Sprintf("%d", len(m.webhooks))
-	if m.totalWebhooks > 0 {
-		countStr = fmt.Sprintf("%d total", m.totalWebhooks)
+	return " " + warningStyle.Render(fmt.Sprintf("[retry #%d of %s]", wh.RetryNum, truncate(wh.DeliveryID, 12)))
+}
+
+func methodStyle(method string) string {
+	switch method {
+	case "GET":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("82")).Render("GET")
+	case "POST":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Render("POST")
+	case "PUT":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render("PUT")
+	case "DELETE":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render("DELETE")
+	case "PATCH":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("141")).Render("PATCH")
+	default:
+		return method
 	}
-	b.WriteString(headerStyle.Render(fmt.Sprintf("Webhooks (%s)", countStr)))
+}
+
+// formatBytes renders a byte count in human-readable units
+func formatBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := int64(n) / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func truncate(s string, max int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	s = strings.ReplaceAll(s, "\r", "")
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
+
+// cutAtRune shortens s to at most max bytes, backing up to a rune boundary
+// so a multi-byte character is never split
+func cutAtRune(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
+// copyToClipboard copies text using the platform clipboard tool, falling back
+// to an OSC52 escape sequence so it also works over SSH
+func copyToClipboard(text, what string) tea.Cmd {
+	return func() tea.Msg {
+		for _, tool := range [][]string{
+			{"pbcopy"},
+			{"wl-copy"},
+			{"xclip", "-selection", "clipboard"},
+			{"xsel", "--clipboard", "--input"},
+		} {
+			if _, err := exec.LookPath(tool[0]); err != nil {
+				continue
+			}
+			cmd := exec.Command(tool[0], tool[1:]...)
+			cmd.Stdin = strings.NewReader(text)
+			if err := cmd.Run(); err == nil {
+				return clipboardMsg{what: what}
+			}
+		}
 
-	// Pagination and view mode info
-	pageInfo := ""
-	if m.totalPages > 1 {
-		pageInfo = fmt.Sprintf(" Page %d/%d |", m.currentPage+1, m.totalPages)
+		// OSC52: the terminal sets the clipboard from the base64 payload
+		terminal.Copy(text)
+		return clipboardMsg{what: what}
 	}
-	b.WriteString(infoStyle.Render(fmt.Sprintf("%s [%s]", pageInfo, viewModeStr)) + "\n")
+}
 
-	if len(m.webhooks) == 0 {
-		b.WriteString(infoStyle.Render("  Waiting for webhooks...") + "\n")
-	} else if m.viewMode == ViewModeTable {
-		b.WriteString(m.renderTableView())
-	} else {
-		b.WriteString(m.renderListView())
+// notifyWindow is the shortest gap between desktop notifications; webhooks
+// arriving in between are counted into one summary
+const notifyWindow = 5 * time.Second
+
+func scheduleNotifyWindow() tea.Cmd {
+	return tea.Tick(notifyWindow, func(time.Time) tea.Msg {
+		return notifyWindowMsg{}
+	})
+}
+
+// sendNotification shows a desktop notification with the first available
+// notifier: terminal-notifier or osascript on macOS, notify-send elsewhere
+func sendNotification(title, body string) tea.Cmd {
+	return func() tea.Msg {
+		for _, tool := range [][]string{
+			{"terminal-notifier", "-title", title, "-message", body},
+			// Pass the text as arguments so it needs no AppleScript quoting
+			{"osascript", "-e", "on run argv", "-e", "display notification (item 2 of argv) with title (item 1 of argv)", "-e", "end run", title, body},
+			{"notify-send", title, body},
+		} {
+			if _, err := exec.LookPath(tool[0]); err != nil {
+				continue
+			}
+			if err := exec.Command(tool[0], tool[1:]...).Run(); err != nil {
+				return notifyFailedMsg(fmt.Sprintf("%s failed: %v - notifications off", tool[0], err))
+			}
+			return nil
+		}
+		return notifyFailedMsg("No notifier found (notify-send, osascript or terminal-notifier) - notifications off")
 	}
+}
 
-	// Help
-	b.WriteString("\n" + helpStyle.Render("j/k: select • n/p: page • Enter: details • t: view • r: reconnect • l: load DB • c: clear • q: quit"))
+// shellQuote single-quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// buildCurl formats a webhook as a curl command against baseURL. Headers curl
+// sets itself are left out, as when replaying.
+func buildCurl(wh WebhookPayload, baseURL string) string {
+	var b strings.Builder
+	b.WriteString("curl -X " + wh.Method + " " + shellQuote(strings.TrimSuffix(baseURL, "/")+wh.requestURI()))
 
+	keys := make([]string, 0, len(wh.Headers))
+	for k := range wh.Headers {
+		if wh.replaysHeader(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range wh.headerValues(k) {
+			b.WriteString(" \\\n  -H " + shellQuote(k+": "+v))
+		}
+	}
+	if wh.Body != "" {
+		b.WriteString(" \\\n  --data-raw " + shellQuote(wh.Body))
+	}
 	return b.String()
 }
 
-func (m Model) renderListView() string {
+// buildMarkdown formats a webhook for pasting into tickets: a heading, a
+// metadata table, the headers and a fenced code block with the body
+func buildMarkdown(wh WebhookPayload) string {
 	var b strings.Builder
 
-	maxShow := 10
-	if len(m.webhooks) < maxShow {
-		maxShow = len(m.webhooks)
+	cell := func(s string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(s, "|", "\\|"), "\n", " ")
 	}
 
-	for i := 0; i < maxShow; i++ {
-		wh := m.webhooks[i]
-		preview := truncate(wh.Body, 50)
-		if preview == "" {
-			preview = "(empty body)"
+	b.WriteString(fmt.Sprintf("## Webhook #%d: %s %s\n\n", wh.ID, wh.Method, wh.Path))
+
+	b.WriteString("| Field | Value |\n|-------|-------|\n")
+	b.WriteString(fmt.Sprintf("| Method | `%s` |\n", wh.Method))
+	b.WriteString(fmt.Sprintf("| Path | `%s` |\n", cell(wh.Path)))
+	b.WriteString(fmt.Sprintf("| Time | %s |\n", wh.Timestamp.Format(time.RFC3339)))
+	if wh.DeliveryID != "" {
+		b.WriteString(fmt.Sprintf("| Delivery ID | `%s` |\n", cell(wh.DeliveryID)))
+	}
+	b.WriteString(fmt.Sprintf("| Body size | %s |\n\n", formatBytes(len(wh.Body))))
+
+	if len(wh.Headers) > 0 {
+		keys := make([]string, 0, len(wh.Headers))
+		for k := range wh.Headers {
+			keys = append(keys, k)
 		}
+		sort.Strings(keys)
 
-		item := fmt.Sprintf("#%d %s %s %s\n    %s",
-			wh.ID,
-			wh.Timestamp.Format("15:04:05"),
-			methodStyle(wh.Method),
-			wh.Path,
-			infoStyle.Render(preview),
-		)
+		b.WriteString("### Headers\n\n| Header | Value |\n|--------|-------|\n")
+		for _, k := range keys {
+			b.WriteString(fmt.Sprintf("| %s | `%s` |\n", cell(k), cell(wh.Headers[k])))
+		}
+		b.WriteString("\n")
+	}
 
-		if i == m.selectedIdx {
-			b.WriteString(webhookSelectedStyle.Render(item) + "\n")
-		} else {
-			b.WriteString(webhookItemStyle.Render(item) + "\n")
+	b.WriteString("### Body\n\n")
+	if note := wh.contentTypeNote(); note != "" {
+		b.WriteString("_" + note + "_\n\n")
+	}
+	body, lang := wh.Body, ""
+	if wh.BodyJSON != nil {
+		if pretty, err := json.MarshalIndent(wh.BodyJSON, "", "  "); err == nil {
+			body, lang = string(pretty), "json"
 		}
 	}
+	if body == "" {
+		b.WriteString("_(empty)_\n")
+	} else {
+		// Use a fence longer than any backtick run in the body
+		fence := "```"
+		for strings.Contains(body, fence) {
+			fence += "`"
+		}
+		b.WriteString(fmt.Sprintf("%s%s\n%s\n%s\n", fence, lang, body, fence))
+	}
 
 	return b.String()
 }
 
-func (m Model) renderTableView() string {
-	var b strings.Builder
+// HAR 1.2 structures (http://www.softwareishard.com/blog/har-12-spec/)
+type harLog struct {
+	Log harContent `json:"log"`
+}
 
-	// Table header
-	tableHeaderStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("39")).
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderBottom(true).
-		BorderForeground(lipgloss.Color("240"))
+type harContent struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
 
-	// Column widths
-	idW := 4
-	timeW := 10
-	methodW := 8
-	pathW := 20
-	bodyW := 40
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
 
-	header := fmt.Sprintf("%-*s %-*s %-*s %-*s %-*s",
-		idW, "ID",
-		timeW, "Time",
-		methodW, "Method",
-		pathW, "Path",
-		bodyW, "Body Preview",
-	)
-	b.WriteString(tableHeaderStyle.Render(header) + "\n")
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
 
-	// Table rows
-	maxShow := 15
-	if len(m.webhooks) < maxShow {
-		maxShow = len(m.webhooks)
-	}
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
 
-	for i := 0; i < maxShow; i++ {
-		wh := m.webhooks[i]
-		preview := truncate(wh.Body, bodyW-3)
-		if preview == "" {
-			preview = "(empty)"
-		}
-		path := truncate(wh.Path, pathW-3)
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harBody        `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
 
-		row := fmt.Sprintf("%-*d %-*s %-*s %-*s %-*s",
-			idW, wh.ID,
-			timeW, wh.Timestamp.Format("15:04:05"),
-			methodW, wh.Method,
-			pathW, path,
-			bodyW, preview,
-		)
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
 
-		if i == m.selectedIdx {
-			rowStyle := lipgloss.NewStyle().
-				Background(lipgloss.Color("236")).
-				Foreground(lipgloss.Color("212"))
-			b.WriteString(rowStyle.Render(row) + "\n")
-		} else {
-			// Color-code method in row
-			methodColored := methodStyle(wh.Method)
-			row = fmt.Sprintf("%-*d %-*s %s%s %-*s %-*s",
-				idW, wh.ID,
-				timeW, wh.Timestamp.Format("15:04:05"),
-				methodColored, strings.Repeat(" ", methodW-len(wh.Method)),
-				pathW, path,
-				bodyW, preview,
-			)
-			b.WriteString(row + "\n")
-		}
-	}
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
 
-	return b.String()
+type harBody struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
 }
 
-func (m Model) buildDetailContent() string {
-	var b strings.Builder
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
 
-	if m.selectedIdx >= len(m.webhooks) {
-		return "No webhook selected"
-	}
+func newHARLog() harLog {
+	return harLog{Log: harContent{
+		Version: "1.2",
+		Creator: harCreator{Name: "webhook-tui", Version: "1.0"},
+		Entries: []harEntry{},
+	}}
+}
 
-	wh := m.webhooks[m.selectedIdx]
+// webhookToHAREntry converts a webhook into a HAR entry. baseURL is the
+// scheme and host the request was sent to (e.g. the tunnel URL).
+func webhookToHAREntry(wh WebhookPayload, baseURL string) harEntry {
+	keys := make([]string, 0, len(wh.Headers))
+	for k := range wh.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
-	// Metadata
-	b.WriteString(fmt.Sprintf("%s %s\n",
-		highlightStyle.Render("Method:"),
-		methodStyle(wh.Method),
-	))
-	b.WriteString(fmt.Sprintf("%s %s\n", highlightStyle.Render("Path:"), wh.Path))
-	b.WriteString(fmt.Sprintf("%s %s\n\n", highlightStyle.Render("Time:"), wh.Timestamp.Format(time.RFC3339)))
+	headers := make([]harNameValue, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range wh.headerValues(k) {
+			headers = append(headers, harNameValue{Name: k, Value: v})
+		}
+	}
 
-	// Headers
-	b.WriteString(headerStyle.Render("Headers") + "\n")
-	for k, v := range wh.Headers {
-		b.WriteString(fmt.Sprintf("  %s: %s\n", highlightStyle.Render(k), v))
+	query := []harNameValue{}
+	for _, p := range wh.queryParams() {
+		query = append(query, harNameValue{Name: p.Name, Value: p.Value})
 	}
-	b.WriteString("\n")
 
-	// Body
-	b.WriteString(headerStyle.Render("Body") + "\n")
-	if wh.BodyJSON != nil {
-		prettyJSON, err := json.MarshalIndent(wh.BodyJSON, "", "  ")
-		if err == nil {
-			b.WriteString(highlightJSON(string(prettyJSON)) + "\n")
-		} else {
-			b.WriteString(bodyStyle.Render(wh.Body) + "\n")
+	req := harRequest{
+		Method:      wh.Method,
+		URL:         strings.TrimSuffix(baseURL, "/") + wh.requestURI(),
+		HTTPVersion: wh.proto(),
+		Cookies:     []harNameValue{},
+		Headers:     headers,
+		QueryString: query,
+		HeadersSize: -1,
+		BodySize:    len(wh.Body),
+	}
+	if wh.Body != "" {
+		mimeType := wh.contentType()
+		if mimeType == "" && wh.BodyJSON != nil {
+			mimeType = "application/json"
 		}
-	} else if wh.Body != "" {
-		b.WriteString(bodyStyle.Render(wh.Body) + "\n")
-	} else {
-		b.WriteString(infoStyle.Render("(empty)") + "\n")
+		req.PostData = &harPostData{MimeType: mimeType, Text: wh.Body}
 	}
 
-	return b.String()
+	// Older rows predate response recording and were always a plain 200 OK;
+	// for newer ones only the status is stored, so the body size is unknown
+	resp := harResponse{
+		Status:      http.StatusOK,
+		StatusText:  "OK",
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     []harNameValue{},
+		Content:     harBody{Size: 2, MimeType: "text/plain", Text: "OK"},
+		HeadersSize: -1,
+		BodySize:    2,
+	}
+	if wh.ResponseStatus != 0 {
+		resp.Status = wh.ResponseStatus
+		resp.StatusText = http.StatusText(wh.ResponseStatus)
+		resp.Content = harBody{Size: -1}
+		resp.BodySize = -1
+	}
+
+	return harEntry{
+		StartedDateTime: wh.Timestamp.Format(time.RFC3339Nano),
+		Request:         req,
+		Response:        resp,
+	}
 }
 
-func (m Model) viewDetail() string {
-	var b strings.Builder
+// replayResult is the response to a replayed request
+type replayResult struct {
+	status   string
+	code     int
+	headers  http.Header
+	body     []byte
+	duration time.Duration
+}
 
-	if m.selectedIdx >= len(m.webhooks) {
-		return "No webhook selected"
+// replaySkipHeaders are recomputed by the HTTP client and must not be copied
+var replaySkipHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Connection":        true,
+	"Accept-Encoding":   true,
+	"Transfer-Encoding": true,
+}
+
+// replaysHeader reports whether header k is sent when replaying or copying
+// as curl. A body stored decompressed goes out without its Content-Encoding.
+func (wh WebhookPayload) replaysHeader(k string) bool {
+	k = http.CanonicalHeaderKey(k)
+	return !replaySkipHeaders[k] && !(k == "Content-Encoding" && wh.Decompressed != "")
+}
+
+// replayWebhook sends a webhook's method, headers and body to target + path
+func replayWebhook(wh WebhookPayload, target string) (replayResult, error) {
+	req, err := http.NewRequest(wh.Method, strings.TrimSuffix(target, "/")+wh.requestURI(), strings.NewReader(wh.Body))
+	if err != nil {
+		return replayResult{}, err
+	}
+	for k := range wh.Headers {
+		if wh.replaysHeader(k) {
+			for _, v := range wh.headerValues(k) {
+				req.Header.Add(k, v)
+			}
+		}
 	}
 
-	wh := m.webhooks[m.selectedIdx]
+	client := &http.Client{Timeout: 30 * time.Second}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return replayResult{}, err
+	}
+	defer resp.Body.Close()
 
-	// Header
-	b.WriteString(headerStyle.Render(fmt.Sprintf("Webhook #%d Details", wh.ID)) + "\n\n")
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return replayResult{}, err
+	}
+	return replayResult{
+		status:   resp.Status,
+		code:     resp.StatusCode,
+		headers:  resp.Header,
+		body:     body,
+		duration: time.Since(start),
+	}, nil
+}
 
-	// Viewport with scrollable content
-	b.WriteString(m.viewport.View() + "\n\n")
+// parseRequestFile reads a saved request: either a webhook as exported in JSON,
+// or an .http file ("POST /path" or "POST https://host/path", headers, blank
+// line, body). Returns the target base URL when the file names one.
+func parseRequestFile(data []byte) (WebhookPayload, string, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var wh WebhookPayload
+		if err := json.Unmarshal(trimmed, &wh); err != nil {
+			return wh, "", fmt.Errorf("invalid request JSON: %w", err)
+		}
+		if wh.Method == "" {
+			wh.Method = http.MethodPost
+		}
+		if wh.Path == "" {
+			wh.Path = "/"
+		}
+		return wh, "", nil
+	}
 
-	// Scroll indicator with optional search info
-	scrollPercent := int(m.viewport.ScrollPercent() * 100)
-	var scrollInfo string
-	if m.searchQuery != "" && len(m.searchMatches) > 0 {
-		scrollInfo = infoStyle.Render(fmt.Sprintf("─── %d%% ─── match %d/%d for '%s' ───",
-			scrollPercent, m.searchMatchIdx+1, len(m.searchMatches), m.searchQuery))
-	} else if m.searchQuery != "" {
-		scrollInfo = infoStyle.Render(fmt.Sprintf("─── %d%% ─── no matches for '%s' ───",
-			scrollPercent, m.searchQuery))
-	} else {
-		scrollInfo = infoStyle.Render(fmt.Sprintf("─── %d%% ───", scrollPercent))
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	// Skip blank lines and comments before the request line
+	i := 0
+	for i < len(lines) {
+		line := strings.TrimSpace(lines[i])
+		if line != "" && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "//") {
+			break
+		}
+		i++
+	}
+	if i == len(lines) {
+		return WebhookPayload{}, "", fmt.Errorf("no request line found")
 	}
-	b.WriteString(scrollInfo + "\n")
 
-	// Help or search input
-	if m.searchMode {
-		b.WriteString(m.searchInput.View())
-	} else {
-		b.WriteString(helpStyle.Render("↑/↓/j/k: scroll • /: search • n/N: next/prev • g/G: top/bottom • Esc: back"))
+	fields := strings.Fields(lines[i])
+	if len(fields) < 2 {
+		return WebhookPayload{}, "", fmt.Errorf("invalid request line %q (want METHOD URL)", lines[i])
+	}
+	wh := WebhookPayload{
+		Method:  strings.ToUpper(fields[0]),
+		Path:    fields[1],
+		Headers: make(map[string]string),
+	}
+	target := ""
+	if u, err := url.Parse(fields[1]); err == nil && u.IsAbs() {
+		target = u.Scheme + "://" + u.Host
+		wh.Path = u.RequestURI()
 	}
 
-	return b.String()
+	for i++; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			i++
+			break
+		}
+		k, v, found := strings.Cut(line, ":")
+		if !found {
+			return WebhookPayload{}, "", fmt.Errorf("invalid header line %q", line)
+		}
+		wh.Headers[http.CanonicalHeaderKey(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+	if i < len(lines) {
+		wh.Body = strings.TrimRight(strings.Join(lines[i:], "\n"), "\n")
+	}
+	return wh, target, nil
 }
 
-// findSearchMatches finds all lines containing the search query
-func (m *Model) findSearchMatches() {
-	m.searchMatches = nil
-	if m.searchQuery == "" || m.detailContent == "" {
-		return
+// exportFormats are the formats understood by exportWebhooks
+var exportFormats = []string{"ndjson", "json", "csv", "har", "go"}
+
+// checkExportFormat rejects formats exportWebhooks doesn't know
+func checkExportFormat(format string) error {
+	for _, f := range exportFormats {
+		if f == format {
+			return nil
+		}
 	}
+	return fmt.Errorf("unknown format %q (use %s)", format, strings.Join(exportFormats, ", "))
+}
 
-	lines := strings.Split(m.detailContent, "\n")
-	query := strings.ToLower(m.searchQuery)
+// exportOptions holds format-specific export settings
+type exportOptions struct {
+	baseURL   string // scheme and host used to reconstruct URLs for HAR
+	goPackage string // package clause of generated Go fixtures
+}
 
-	for i, line := range lines {
-		// Strip ANSI codes for searching
-		cleanLine := stripANSI(line)
-		if strings.Contains(strings.ToLower(cleanLine), query) {
-			m.searchMatches = append(m.searchMatches, i)
-		}
+// goFixturesTemplate renders webhooks as httptest request builders. Every
+// string goes through quote, so any body or header produces valid Go; the
+// imports only the builders need are left out when there are none.
+var goFixturesTemplate = template.Must(template.New("fixtures").Funcs(template.FuncMap{
+	"quote":  strconv.Quote,
+	"target": WebhookPayload.requestURI,
+	"time":   func(t time.Time) string { return t.Format(time.RFC3339Nano) },
+}).Parse(`// Code generated by webhook-tui export --format go. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"net/http"
+{{- if .Webhooks}}
+	"net/http/httptest"
+	"strings"
+{{- end}}
+)
+{{range .Webhooks}}
+// Webhook{{.ID}} rebuilds {{.Method}} {{printf "%q" (target .)}} captured at {{time .Timestamp}}.
+func Webhook{{.ID}}() *http.Request {
+	req := httptest.NewRequest({{quote .Method}}, {{quote (target .)}}, strings.NewReader({{quote .Body}}))
+{{- range $k, $v := .Headers}}
+	req.Header.Set({{quote $k}}, {{quote $v}})
+{{- end}}
+	return req
+}
+{{end}}
+// All returns fresh copies of every captured request, oldest first.
+func All() []*http.Request {
+	return []*http.Request{
+{{- range .Webhooks}}
+		Webhook{{.ID}}(),
+{{- end}}
 	}
 }
+`))
 
-// updateDetailViewport updates the viewport content with line numbers and search highlighting
-func (m *Model) updateDetailViewport() {
-	if m.detailContent == "" {
-		return
+// writeGoFixtures generates a gofmt'ed Go file with one request builder per webhook
+func writeGoFixtures(w io.Writer, pkg string, webhooks []WebhookPayload) error {
+	if pkg == "" {
+		pkg = "fixtures"
+	}
+	if !token.IsIdentifier(pkg) {
+		return fmt.Errorf("invalid package name %q", pkg)
 	}
 
-	var content string
-	if m.searchQuery != "" {
-		content = highlightSearchMatches(m.detailContent, m.searchQuery)
-	} else {
-		content = m.detailContent
+	var buf bytes.Buffer
+	err := goFixturesTemplate.Execute(&buf, struct {
+		Package  string
+		Webhooks []WebhookPayload
+	}{pkg, webhooks})
+	if err != nil {
+		return err
 	}
 
-	numbered := addLineNumbers(content, m.detailGutterWidth)
-	m.viewport.SetContent(numbered)
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("generated fixtures don't parse: %w", err)
+	}
+	_, err = w.Write(src)
+	return err
 }
 
-// highlightSearchMatches highlights all occurrences of query in the content
-func highlightSearchMatches(content, query string) string {
-	if query == "" {
-		return content
-	}
+// exportWebhooks streams webhooks matching the filter to w in the given
+// format, oldest first
+func exportWebhooks(w io.Writer, format string, filter webhookFilter, opts exportOptions) (int, error) {
+	count := 0
+
+	switch format {
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		err := forEachWebhook(filter, func(wh WebhookPayload) error {
+			count++
+			return enc.Encode(wh)
+		})
+		return count, err
 
-	lines := strings.Split(content, "\n")
-	var result strings.Builder
+	case "json":
+		// Stream the array so memory stays flat for large exports
+		if _, err := io.WriteString(w, "["); err != nil {
+			return 0, err
+		}
+		err := forEachWebhook(filter, func(wh WebhookPayload) error {
+			data, err := json.MarshalIndent(wh, "  ", "  ")
+			if err != nil {
+				return err
+			}
+			sep := ",\n  "
+			if count == 0 {
+				sep = "\n  "
+			}
+			count++
+			_, err = io.WriteString(w, sep+string(data))
+			return err
+		})
+		if err != nil {
+			return count, err
+		}
+		_, err = io.WriteString(w, "\n]\n")
+		return count, err
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"id", "timestamp", "method", "path", "headers", "body"})
+		err := forEachWebhook(filter, func(wh WebhookPayload) error {
+			count++
+			headers, _ := json.Marshal(wh.Headers)
+			return cw.Write([]string{
+				strconv.Itoa(wh.ID),
+				wh.Timestamp.Format(time.RFC3339),
+				wh.Method,
+				wh.Path,
+				string(headers),
+				wh.Body,
+			})
+		})
+		cw.Flush()
+		if err == nil {
+			err = cw.Error()
+		}
+		return count, err
+
+	case "har":
+		// Stream the entries between a hand-written log header and footer,
+		// laid out as encoding the whole harLog would
+		har := newHARLog().Log
+		version, _ := json.Marshal(har.Version)
+		creator, err := json.MarshalIndent(har.Creator, "    ", "  ")
+		if err != nil {
+			return 0, err
+		}
+		if _, err := fmt.Fprintf(w, "{\n  \"log\": {\n    \"version\": %s,\n    \"creator\": %s,\n    \"entries\": [", version, creator); err != nil {
+			return 0, err
+		}
+		err = forEachWebhook(filter, func(wh WebhookPayload) error {
+			data, err := json.MarshalIndent(webhookToHAREntry(wh, opts.baseURL), "      ", "  ")
+			if err != nil {
+				return err
+			}
+			sep := ",\n      "
+			if count == 0 {
+				sep = "\n      "
+			}
+			count++
+			_, err = io.WriteString(w, sep+string(data))
+			return err
+		})
+		if err != nil {
+			return count, err
+		}
+		end := "\n    ]\n  }\n}\n"
+		if count == 0 {
+			end = "]\n  }\n}\n"
+		}
+		_, err = io.WriteString(w, end)
+		return count, err
 
-	for i, line := range lines {
-		result.WriteString(highlightLineMatches(line, query))
-		if i < len(lines)-1 {
-			result.WriteString("\n")
+	case "go":
+		var webhooks []WebhookPayload
+		err := forEachWebhook(filter, func(wh WebhookPayload) error {
+			webhooks = append(webhooks, wh)
+			return nil
+		})
+		if err != nil {
+			return 0, err
 		}
+		return len(webhooks), writeGoFixtures(w, opts.goPackage, webhooks)
+
+	default:
+		return 0, checkExportFormat(format)
 	}
+}
 
-	return result.String()
+// sessionMeta describes the capture settings of the running session
+type sessionMeta struct {
+	ExportedAt    time.Time `json:"exported_at"`
+	PublicIP      string    `json:"public_ip"`
+	TunnelURL     string    `json:"tunnel_url"`
+	Port          string    `json:"port"`
+	Subdomain     string    `json:"subdomain,omitempty"`
+	TunnelTimeout string    `json:"tunnel_timeout"`
+	Filter        string    `json:"filter,omitempty"`
+	Count         int       `json:"count"`
 }
 
-// highlightLineMatches highlights matches in a single line (case-insensitive)
-func highlightLineMatches(line, query string) string {
-	if query == "" {
-		return line
+func (m Model) sessionMeta() sessionMeta {
+	return sessionMeta{
+		ExportedAt:    time.Now(),
+		PublicIP:      m.publicIP,
+		TunnelURL:     m.tunnelURL,
+		Port:          m.requestedPort,
+		Subdomain:     m.requestedSubdomain,
+		TunnelTimeout: m.tunnelTimeout.String(),
+		Filter:        m.filter.String(),
 	}
+}
 
-	lowerLine := strings.ToLower(stripANSI(line))
-	lowerQuery := strings.ToLower(query)
-
-	// If no match in this line, return as-is
-	if !strings.Contains(lowerLine, lowerQuery) {
-		return line
+// baseURL is the public URL webhooks were sent to, or the local server if no tunnel is up
+func (m Model) baseURL() string {
+	if m.tunnelURL != "" {
+		return m.tunnelURL
 	}
+	port := m.requestedPort
+	if port == "" {
+		port = "8098"
+	}
+	return "http://localhost:" + port
+}
 
-	// For lines with ANSI codes, we need to be careful
-	// Simple approach: find matches in clean text, then highlight in original
-	// This is tricky with ANSI codes, so let's do a simpler approach:
-	// Replace matches case-insensitively
-	var result strings.Builder
-	remaining := line
-
-	for len(remaining) > 0 {
-		// Find next match (case-insensitive) in the remaining string
-		cleanRemaining := strings.ToLower(stripANSI(remaining))
-		idx := strings.Index(cleanRemaining, lowerQuery)
+// exportBundle writes a zip with the filtered webhooks as JSON and HAR, the
+// session metadata and a README describing the capture
+func exportBundle(filter webhookFilter, meta sessionMeta, baseURL string) tea.Cmd {
+	return func() tea.Msg {
+		path := filepath.Join(filepath.Dir(dbPath), fmt.Sprintf("bundle-%s.zip", meta.ExportedAt.Format("20060102-150405")))
+		f, err := os.Create(path)
+		if err != nil {
+			return exportErrorMsg(fmt.Sprintf("Failed to create bundle: %v", err))
+		}
+		defer f.Close()
+
+		// Stream the webhooks into the zip like the other exports
+		zw := zip.NewWriter(f)
+		opts := exportOptions{baseURL: baseURL}
+		for _, file := range []struct{ name, format string }{
+			{"webhooks.json", "json"},
+			{"webhooks.har", "har"},
+		} {
+			w, err := zw.Create(file.name)
+			if err != nil {
+				return exportErrorMsg(fmt.Sprintf("Failed to write bundle: %v", err))
+			}
+			count, err := exportWebhooks(w, file.format, filter, opts)
+			if err != nil {
+				return exportErrorMsg(fmt.Sprintf("Failed to write %s: %v", file.name, err))
+			}
+			if file.format == "json" {
+				meta.Count = count
+			}
+		}
 
-		if idx == -1 {
-			result.WriteString(remaining)
-			break
+		w, err := zw.Create("session.json")
+		if err != nil {
+			return exportErrorMsg(fmt.Sprintf("Failed to write bundle: %v", err))
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(meta); err != nil {
+			return exportErrorMsg(fmt.Sprintf("Failed to write session.json: %v", err))
 		}
 
-		// Find the actual position in the string with ANSI codes
-		actualIdx := findActualIndex(remaining, idx)
+		w, err = zw.Create("README.md")
+		if err != nil {
+			return exportErrorMsg(fmt.Sprintf("Failed to write bundle: %v", err))
+		}
+		fmt.Fprintf(w, "# webhook-tui capture\n\n")
+		fmt.Fprintf(w, "| Setting | Value |\n|---------|-------|\n")
+		fmt.Fprintf(w, "| Exported at | %s |\n", meta.ExportedAt.Format(time.RFC3339))
+		fmt.Fprintf(w, "| Tunnel URL | %s |\n", meta.TunnelURL)
+		fmt.Fprintf(w, "| Public IP | %s |\n", meta.PublicIP)
+		fmt.Fprintf(w, "| Local port | %s |\n", meta.Port)
+		fmt.Fprintf(w, "| Subdomain | %s |\n", meta.Subdomain)
+		fmt.Fprintf(w, "| Tunnel timeout | %s |\n", meta.TunnelTimeout)
+		fmt.Fprintf(w, "| Filter | %s |\n", meta.Filter)
+		fmt.Fprintf(w, "| Webhooks | %d |\n\n", meta.Count)
+		fmt.Fprintf(w, "Files: `webhooks.json` (captured requests), `webhooks.har` (load in browser devtools), `session.json` (settings above).\n")
+
+		if err := zw.Close(); err != nil {
+			return exportErrorMsg(fmt.Sprintf("Failed to finish bundle: %v", err))
+		}
+		return exportDoneMsg{path: path, count: meta.Count}
+	}
+}
 
-		// Write everything before the match
-		result.WriteString(remaining[:actualIdx])
+// exportToFile writes every stored webhook in the given format to name,
+// next to the database
+func exportToFile(format, name string, opts exportOptions) tea.Cmd {
+	return func() tea.Msg {
+		path := filepath.Join(filepath.Dir(dbPath), name)
+		f, err := os.Create(path)
+		if err != nil {
+			return exportErrorMsg(fmt.Sprintf("Failed to create %s: %v", path, err))
+		}
 
-		// Find the end of the match (accounting for ANSI codes)
-		matchEnd := findActualIndex(remaining, idx+len(query))
+		w := bufio.NewWriter(f)
+		count, err := exportWebhooks(w, format, webhookFilter{}, opts)
+		if err == nil {
+			err = w.Flush()
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return exportErrorMsg(fmt.Sprintf("Export to %s failed after %d webhooks: %v", path, count, err))
+		}
+		return exportDoneMsg{path: path, count: count}
+	}
+}
 
-		// Extract and highlight the match
-		match := remaining[actualIdx:matchEnd]
-		result.WriteString(searchHighlightStyle.Render(stripANSI(match)))
+// runCLI handles headless subcommands. Returns false if args don't name one,
+// in which case the TUI is started.
+func runCLI(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
 
-		remaining = remaining[matchEnd:]
+	switch args[0] {
+	case "cat":
+		return true, runCat(args[1:])
+	case "list":
+		return true, runList(args[1:])
+	case "export":
+		return true, runExport(args[1:])
+	case "meta":
+		return true, runMeta(args[1:])
+	case "send":
+		return true, runSend(args[1:])
+	case "replay":
+		return true, runReplay(args[1:])
+	default:
+		return false, nil
 	}
+}
 
-	return result.String()
+// addFilterFlags registers the filter flags shared by subcommands and returns
+// a function building the webhookFilter once flags are parsed
+func addFilterFlags(fs *flag.FlagSet) func() (webhookFilter, error) {
+	method := fs.String("method", "", "only webhooks with this HTTP method")
+	path := fs.String("path", "", "only webhooks whose path contains this")
+	since := fs.Duration("since", 0, "only webhooks received within this duration (e.g. 1h)")
+	body := fs.String("body", "", "only webhooks whose body contains this")
+	meta := fs.String("meta", "", "only webhooks with this metadata key (or key=value)")
+	run := fs.String("run", "", "only webhooks captured under this WEBHOOK_TUI_RUN_ID")
+	field := fs.String("field", "", "only webhooks whose JSON body has this path (or path=value)")
+	re := fs.String("regex", "", "only webhooks whose path, headers or body match this regular expression")
+	return func() (webhookFilter, error) {
+		f := webhookFilter{
+			Method: strings.ToUpper(*method),
+			Path:   *path,
+			Since:  *since,
+			Body:   *body,
+			RunID:  *run,
+			Regex:  *re,
+		}
+		f.MetaKey, f.MetaValue, _ = strings.Cut(*meta, "=")
+		if *field != "" {
+			f.JSONPath, f.JSONValue, _ = strings.Cut(*field, "=")
+			if err := validateJSONPath(f.JSONPath); err != nil {
+				return f, fmt.Errorf("invalid --field %q: %v", *field, err)
+			}
+		}
+		return f, nil
+	}
 }
 
-// findActualIndex finds the actual byte index in a string with ANSI codes
-// given a visual character index (ignoring ANSI codes)
-func findActualIndex(s string, visualIdx int) int {
-	ansiPattern := regexp.MustCompile(`\x1b\[[0-9;]*m`)
+// runList prints the newest stored webhooks as a table or JSON
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	limit := fs.Int("limit", config.pageSize(), "maximum number of webhooks")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	buildFilter := addFilterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	filter, err := buildFilter()
+	if err != nil {
+		return err
+	}
 
-	actualIdx := 0
-	visualCount := 0
+	webhooks, err := queryWebhooks(filter, *limit, 0)
+	if err != nil {
+		return err
+	}
 
-	for actualIdx < len(s) && visualCount < visualIdx {
-		// Check if we're at the start of an ANSI sequence
-		if loc := ansiPattern.FindStringIndex(s[actualIdx:]); loc != nil && loc[0] == 0 {
-			// Skip the ANSI sequence
-			actualIdx += loc[1]
-		} else {
-			// Regular character
-			actualIdx++
-			visualCount++
+	if *asJSON {
+		if webhooks == nil {
+			webhooks = []WebhookPayload{}
 		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(webhooks)
 	}
 
-	return actualIdx
-}
-
-// stripANSI removes ANSI escape codes from a string
-func stripANSI(s string) string {
-	ansiPattern := regexp.MustCompile(`\x1b\[[0-9;]*m`)
-	return ansiPattern.ReplaceAllString(s, "")
-}
-
-// wrapContent wraps text to the specified width while preserving ANSI escape codes
-func wrapContent(content string, width int) string {
-	// wrap.String is ANSI-aware and will hard-wrap at the specified width
-	return wrap.String(content, width)
+	for _, wh := range webhooks {
+		fmt.Printf("%-6d %s  %-7s %-30s %s\n",
+			wh.ID,
+			wh.Timestamp.Format("2006-01-02 15:04:05"),
+			wh.Method,
+			truncate(wh.Path, 27),
+			truncate(wh.Body, 60),
+		)
+	}
+	return nil
 }
 
-// highlightJSON applies syntax highlighting to JSON text
-func highlightJSON(jsonStr string) string {
-	var result strings.Builder
-	lines := strings.Split(jsonStr, "\n")
+// runExport writes stored webhooks to a file or stdout
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "ndjson", "output format: "+strings.Join(exportFormats, ", "))
+	output := fs.String("output", "", "output file (default stdout)")
+	baseURL := fs.String("host", "http://localhost", "scheme and host used to build URLs in HAR output")
+	goPackage := fs.String("package", "fixtures", "package name for Go fixtures")
+	buildFilter := addFilterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	filter, err := buildFilter()
+	if err != nil {
+		return err
+	}
+	// Check before creating the file so a typo doesn't truncate it
+	if err := checkExportFormat(*format); err != nil {
+		return err
+	}
 
-	for i, line := range lines {
-		result.WriteString(highlightJSONLine(line))
-		if i < len(lines)-1 {
-			result.WriteString("\n")
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
 		}
+		defer f.Close()
+		w = f
 	}
 
-	return result.String()
+	count, err := exportWebhooks(w, *format, filter, exportOptions{baseURL: *baseURL, goPackage: *goPackage})
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d webhooks to %s\n", count, *output)
+	}
+	return nil
 }
 
-// highlightJSONLine highlights a single line of JSON
-func highlightJSONLine(line string) string {
-	trimmed := strings.TrimSpace(line)
-	indent := line[:len(line)-len(trimmed)]
-
-	// Empty or whitespace-only line
-	if trimmed == "" {
-		return line
+// runMeta prints a stored webhook's metadata, or sets keys given as
+// key=value (key= removes it)
+func runMeta(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: webhook-tui meta <id> [key=value ...]")
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		return fmt.Errorf("invalid id %q", args[0])
 	}
 
-	// Bracket-only lines
-	if trimmed == "{" || trimmed == "}" || trimmed == "[" || trimmed == "]" ||
-		trimmed == "{," || trimmed == "}," || trimmed == "[," || trimmed == "]," {
-		bracket := strings.TrimSuffix(trimmed, ",")
-		comma := ""
-		if strings.HasSuffix(trimmed, ",") {
-			comma = ","
+	set := make(map[string]string)
+	for _, arg := range args[1:] {
+		k, v, found := strings.Cut(arg, "=")
+		if !found || k == "" {
+			return fmt.Errorf("invalid metadata %q (use key=value)", arg)
 		}
-		return indent + jsonBracketStyle.Render(bracket) + comma
+		set[k] = v
 	}
 
-	// Check if line has a key (starts with ")
-	if strings.HasPrefix(trimmed, "\"") {
-		colonIdx := strings.Index(trimmed, "\":")
-		if colonIdx > 0 {
-			// This is a key: value line
-			key := trimmed[:colonIdx+1]
-			rest := trimmed[colonIdx+2:] // skip ":
+	var metadata map[string]string
+	if len(set) > 0 {
+		metadata, err = updateMetadata(id, set)
+	} else {
+		var wh WebhookPayload
+		wh, err = loadWebhookByID(id)
+		metadata = wh.Metadata
+	}
+	if err != nil {
+		return err
+	}
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
 
-			var result strings.Builder
-			result.WriteString(indent)
-			result.WriteString(jsonKeyStyle.Render(key))
-			result.WriteString(": ")
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(metadata)
+}
 
-			value := strings.TrimSpace(rest)
-			result.WriteString(highlightJSONValue(value))
-			return result.String()
-		}
+// runSend fires a request loaded from a file and prints the response
+func runSend(args []string) error {
+	fs := flag.NewFlagSet("send", flag.ContinueOnError)
+	target := fs.String("target", "", "scheme and host to send to (default: the file's URL, else http://localhost:8098)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: webhook-tui send [--target URL] <file.http|file.json>")
 	}
 
-	// Array element (string, number, etc.)
-	return indent + highlightJSONValue(trimmed)
-}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	wh, fileTarget, err := parseRequestFile(data)
+	if err != nil {
+		return err
+	}
 
-// highlightJSONValue highlights a JSON value
-func highlightJSONValue(value string) string {
-	// Remove trailing comma for analysis
-	hasComma := strings.HasSuffix(value, ",")
-	cleanValue := strings.TrimSuffix(value, ",")
-	comma := ""
-	if hasComma {
-		comma = ","
+	base := *target
+	if base == "" {
+		base = fileTarget
+	}
+	if base == "" {
+		base = "http://localhost:8098"
 	}
 
-	// String value
-	if strings.HasPrefix(cleanValue, "\"") && strings.HasSuffix(cleanValue, "\"") {
-		return jsonStringStyle.Render(cleanValue) + comma
+	res, err := replayWebhook(wh, base)
+	if err != nil {
+		return err
 	}
 
-	// Boolean
-	if cleanValue == "true" || cleanValue == "false" {
-		return jsonBoolStyle.Render(cleanValue) + comma
+	fmt.Fprintf(os.Stderr, "%s %s%s -> %s (%s)\n", wh.Method, base, wh.requestURI(), res.status, res.duration.Round(time.Millisecond))
+	os.Stdout.Write(res.body)
+	if len(res.body) > 0 && res.body[len(res.body)-1] != '\n' {
+		fmt.Println()
 	}
+	return nil
+}
 
-	// Null
-	if cleanValue == "null" {
-		return jsonNullStyle.Render(cleanValue) + comma
+// runReplay re-sends stored webhooks to a target, oldest first, keeping the
+// original gaps between them (scaled by --speed). Ctrl+C stops after the
+// request in flight.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	target := fs.String("target", "http://localhost:8098", "scheme and host to send to")
+	speed := fs.Float64("speed", 1, "speed multiplier for the original timing (2 = twice as fast)")
+	maxGap := fs.Duration("max-gap", 0, "cap on any single wait, e.g. 10s (0 = no cap)")
+	buildFilter := addFilterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *speed <= 0 {
+		return fmt.Errorf("--speed must be positive")
+	}
+	filter, err := buildFilter()
+	if err != nil {
+		return err
 	}
 
-	// Number (int or float)
-	if regexp.MustCompile(`^-?\d+\.?\d*([eE][+-]?\d+)?$`).MatchString(cleanValue) {
-		return jsonNumberStyle.Render(cleanValue) + comma
+	var webhooks []WebhookPayload
+	if err := forEachWebhook(filter, func(wh WebhookPayload) error {
+		webhooks = append(webhooks, wh)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return fmt.Errorf("no webhooks match")
 	}
 
-	// Array/object start
-	if cleanValue == "[" || cleanValue == "{" {
-		return jsonBracketStyle.Render(cleanValue) + comma
+	// Scaled wait before each webhook; the first one goes out immediately
+	waits := make([]time.Duration, len(webhooks))
+	var remaining time.Duration
+	for i := 1; i < len(webhooks); i++ {
+		gap := time.Duration(float64(webhooks[i].Timestamp.Sub(webhooks[i-1].Timestamp)) / *speed)
+		if gap < 0 {
+			gap = 0
+		}
+		if *maxGap > 0 && gap > *maxGap {
+			gap = *maxGap
+		}
+		waits[i] = gap
+		remaining += gap
 	}
 
-	// Default - return as-is
-	return value
-}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-// addLineNumbers adds vim-style line numbers to content
-func addLineNumbers(content string, gutterWidth int) string {
-	lines := strings.Split(content, "\n")
-	var result strings.Builder
+	fmt.Fprintf(os.Stderr, "Replaying %d webhooks to %s over ~%s\n", len(webhooks), *target, remaining.Round(time.Second))
+	sent, failed := 0, 0
+	for i, wh := range webhooks {
+		if waits[i] > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(waits[i]):
+			}
+			remaining -= waits[i]
+		}
+		if ctx.Err() != nil {
+			fmt.Fprintf(os.Stderr, "Cancelled\n")
+			break
+		}
 
-	for i, line := range lines {
-		lineNum := fmt.Sprintf("%*d", gutterWidth, i+1)
-		result.WriteString(lineNumberStyle.Render(lineNum))
-		result.WriteString(" │ ")
-		result.WriteString(line)
-		if i < len(lines)-1 {
-			result.WriteString("\n")
+		res, err := replayWebhook(wh, *target)
+		sent++
+		result := ""
+		if err != nil {
+			failed++
+			result = "error: " + err.Error()
+		} else {
+			result = fmt.Sprintf("%s (%s)", res.status, res.duration.Round(time.Millisecond))
+			if !strings.HasPrefix(res.status, "2") {
+				failed++
+			}
 		}
+		fmt.Fprintf(os.Stderr, "[%d/%d] #%d %s %s -> %s  ETA %s\n",
+			i+1, len(webhooks), wh.ID, wh.Method, wh.requestURI(), result, remaining.Round(time.Second))
 	}
 
-	return result.String()
+	fmt.Fprintf(os.Stderr, "Sent %d of %d, %d failed\n", sent, len(webhooks), failed)
+	if failed > 0 {
+		return fmt.Errorf("%d replays failed", failed)
+	}
+	return nil
 }
 
-func methodStyle(method string) string {
-	switch method {
-	case "GET":
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("82")).Render("GET")
-	case "POST":
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Render("POST")
-	case "PUT":
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render("PUT")
-	case "DELETE":
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render("DELETE")
-	case "PATCH":
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("141")).Render("PATCH")
-	default:
-		return method
+// runCat prints a stored webhook's body, pretty-printed if it is JSON
+func runCat(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: webhook-tui cat <id>")
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		return fmt.Errorf("invalid id %q", args[0])
 	}
-}
 
-func truncate(s string, max int) string {
-	s = strings.ReplaceAll(s, "\n", " ")
-	s = strings.ReplaceAll(s, "\r", "")
-	if len(s) <= max {
-		return s
+	wh, err := loadWebhookByID(id)
+	if err != nil {
+		return err
 	}
-	return s[:max] + "..."
+
+	if wh.BodyJSON != nil {
+		pretty, err := json.MarshalIndent(wh.BodyJSON, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(pretty))
+	} else {
+		fmt.Print(wh.Body)
+	}
+	return nil
 }
 
 func main() {
+	config = loadConfig()
+	if err := config.validate(); err != nil {
+		fmt.Printf("Invalid config %s: %v\n", configPath, err)
+		os.Exit(1)
+	}
+
+	// The TUI owns the terminal, so log output goes to a file or nowhere
+	log.SetOutput(io.Discard)
+	if config.DebugLog != "" {
+		f, err := tea.LogToFile(config.DebugLog, "webhook-tui")
+		if err != nil {
+			fmt.Printf("Failed to open debug log: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+	}
+
 	// Initialize database
 	if err := initDB(); err != nil {
 		fmt.Printf("Failed to initialize database: %v\n", err)
@@ -1485,8 +8994,21 @@ func main() {
 	}
 	defer db.Close()
 
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	if handled, err := runCLI(os.Args[1:]); handled {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			db.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	p := tea.NewProgram(initialModel(), tea.WithAltScreen(), tea.WithOutput(terminal))
+	_, err := p.Run()
+	if config.ListenSocket != "" {
+		removeSocket(config.ListenSocket)
+	}
+	if err != nil {
 		fmt.Printf("Error running program: %v\n", err)
 		os.Exit(1)
 	}
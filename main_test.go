@@ -0,0 +1,888 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// openTestDB points the package database at a fresh file for one test
+func openTestDB(t *testing.T) {
+	t.Helper()
+	oldPath := dbPath
+	dbPath = filepath.Join(t.TempDir(), "webhooks.db")
+	if err := initDB(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		db = nil
+		dbPath = oldPath
+	})
+}
+
+func TestWriteGoFixturesCompiles(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	webhooks := []WebhookPayload{
+		{ID: 1, Timestamp: time.Now(), Method: "POST", Path: "/hooks/stripe", RawURI: "/hooks/stripe?x=1",
+			Headers: map[string]string{"Content-Type": "application/json"}, Body: `{"a": "b\"c` + "`" + `"}`},
+		{ID: 2, Timestamp: time.Now(), Method: "GET", Path: "/ping"},
+	}
+	for _, tc := range []struct {
+		name     string
+		webhooks []WebhookPayload
+	}{
+		{"empty", nil},
+		{"webhooks", webhooks},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var buf bytes.Buffer
+			if err := writeGoFixtures(&buf, "fixtures", tc.webhooks); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "fixtures.go"), buf.Bytes(), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module fixtures\n\ngo 1.21\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cmd := exec.Command(goBin, "vet", ".")
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("generated fixtures don't compile: %v\n%s\n%s", err, out, buf.String())
+			}
+		})
+	}
+}
+
+func TestTimestampsRoundTripSubSecond(t *testing.T) {
+	openTestDB(t)
+
+	// Both in the same second, in a zone other than UTC
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, zone)
+	early := base.Add(500 * time.Millisecond)
+	late := base.Add(700*time.Millisecond + 123)
+	for _, ts := range []time.Time{early, late} {
+		if _, err := saveWebhookToDB(WebhookPayload{Timestamp: ts, Method: "POST", Path: "/a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := queryWebhooks(webhookFilter{}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d webhooks, want 2", len(got))
+	}
+	if !got[0].Timestamp.Equal(late) || !got[1].Timestamp.Equal(early) {
+		t.Errorf("timestamps = %v, %v; want %v, %v", got[0].Timestamp, got[1].Timestamp, late, early)
+	}
+
+	// A cutoff inside the same second only selects the later webhook
+	where, args := cleanupBucket{from: base.Add(600 * time.Millisecond)}.whereClause()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM webhooks"+where, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("rows at or after the cutoff = %d, want 1", n)
+	}
+	where, args = retentionBucket(0, base.Add(600*time.Millisecond)).whereClause()
+	if err := db.QueryRow("SELECT COUNT(*) FROM webhooks"+where, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("rows before the cutoff = %d, want 1", n)
+	}
+}
+
+func TestNormalizeTimestamps(t *testing.T) {
+	openTestDB(t)
+
+	want := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+	for _, value := range []string{want.Format(time.RFC3339), want.UTC().Format("2006-01-02 15:04:05")} {
+		if _, err := db.Exec("INSERT INTO webhooks (timestamp, method, path) VALUES (?, 'POST', '/')", value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := normalizeTimestamps("webhooks", "timestamp"); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := db.Query("SELECT CAST(timestamp AS TEXT) FROM webhooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			t.Fatal(err)
+		}
+		if value != dbTime(want) {
+			t.Errorf("stored %q, want %q", value, dbTime(want))
+		}
+	}
+}
+
+func TestConcurrentWebhooksAreAllStored(t *testing.T) {
+	openTestDB(t)
+	m := initialModel()
+	srv := httptest.NewServer(m.webhookMux())
+	defer srv.Close()
+
+	// Stand in for the UI so the live view never fills up
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-m.webhookChan:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	const n = 1000
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := http.Post(srv.URL+"/stress", "application/json", strings.NewReader(fmt.Sprintf(`{"n": %d}`, i)))
+			if err != nil {
+				errs <- err
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				errs <- fmt.Errorf("request %d: status %d", i, resp.StatusCode)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var total, distinct, maxID int
+	if err := db.QueryRow("SELECT COUNT(*), COUNT(DISTINCT json_extract(body_json, '$.n')), MAX(id) FROM webhooks").Scan(&total, &distinct, &maxID); err != nil {
+		t.Fatal(err)
+	}
+	if total != n || distinct != n {
+		t.Errorf("stored %d webhooks with %d distinct bodies, want %d", total, distinct, n)
+	}
+	// Ids come from the inserts, so they count up without gaps
+	if maxID != n {
+		t.Errorf("highest id %d, want %d", maxID, n)
+	}
+}
+
+func TestSelectionAfterReload(t *testing.T) {
+	page := []WebhookPayload{{ID: 9}, {ID: 7}, {ID: 4}}
+	for _, tc := range []struct {
+		name       string
+		webhooks   []WebhookPayload
+		selectedID int
+		want       int
+	}{
+		{"selected id kept", page, 4, 2},
+		{"selected id gone", page, 5, 0},
+		{"nothing selected", page, 0, 0},
+		{"empty page", nil, 7, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := selectionAfterReload(tc.webhooks, tc.selectedID); got != tc.want {
+				t.Errorf("selectionAfterReload(%d) = %d, want %d", tc.selectedID, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildMarkdown(t *testing.T) {
+	wh := WebhookPayload{
+		ID:        42,
+		Timestamp: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Method:    "POST",
+		Path:      "/hooks/a|b",
+		Headers:   map[string]string{"X-B": "2", "X-A": "line\nbreak"},
+		Body:      "before ``` after",
+	}
+	md := buildMarkdown(wh)
+	for _, want := range []string{
+		"## Webhook #42: POST /hooks/a|b\n",
+		"| Path | `/hooks/a\\|b` |\n",
+		"| X-A | `line break` |\n| X-B | `2` |\n",
+		"````\nbefore ``` after\n````\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+
+	wh.Headers = nil
+	wh.Body, wh.BodyJSON = `{"a":1}`, map[string]interface{}{"a": 1}
+	if md := buildMarkdown(wh); !strings.Contains(md, "```json\n{\n  \"a\": 1\n}\n```\n") || strings.Contains(md, "### Headers") {
+		t.Errorf("JSON body not pretty-printed, or empty headers listed:\n%s", md)
+	}
+
+	wh.Body, wh.BodyJSON = "", nil
+	if md := buildMarkdown(wh); !strings.Contains(md, "_(empty)_") {
+		t.Errorf("empty body not marked:\n%s", md)
+	}
+}
+
+func TestContentTypeNote(t *testing.T) {
+	jsonBody := map[string]interface{}{"a": 1}
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		bodyJSON    interface{}
+		overrides   map[string]string
+		want        string
+	}{
+		{"declared JSON", "application/json; charset=utf-8", jsonBody, nil, ""},
+		{"vendor JSON", "application/vnd.api+json", jsonBody, nil, ""},
+		{"no header", "", jsonBody, nil, "no Content-Type header, body parsed as JSON"},
+		{"mislabeled", "text/plain", jsonBody, nil, "Content-Type is text/plain, body parsed as JSON"},
+		{"not JSON", "text/plain", nil, nil, ""},
+		{"overridden", "text/plain", jsonBody, map[string]string{"/hooks": "application/json"}, "Content-Type overridden to application/json, sent as text/plain"},
+		{"overridden, no header", "", nil, map[string]string{"*": "text/xml"}, "Content-Type overridden to text/xml, sent as none"},
+		{"override for another path", "", jsonBody, map[string]string{"/other": "text/plain"}, "no Content-Type header, body parsed as JSON"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			old := config.ContentTypeOverrides
+			config.ContentTypeOverrides = tc.overrides
+			defer func() { config.ContentTypeOverrides = old }()
+
+			wh := WebhookPayload{Path: "/hooks/a", BodyJSON: tc.bodyJSON, Headers: map[string]string{}}
+			if tc.contentType != "" {
+				wh.Headers["content-type"] = tc.contentType
+			}
+			if got := wh.contentTypeNote(); got != tc.want {
+				t.Errorf("contentTypeNote() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSubSecondInsertsKeepDistinctOrderedTimestamps(t *testing.T) {
+	openTestDB(t)
+
+	first := time.Now()
+	second := first.Add(time.Millisecond)
+	for _, ts := range []time.Time{first, second} {
+		if _, err := saveWebhookToDB(WebhookPayload{Timestamp: ts, Method: "POST", Path: "/a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := db.Query("SELECT id, CAST(timestamp AS TEXT) FROM webhooks ORDER BY timestamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var ids []int
+	var stamps []string
+	for rows.Next() {
+		var id int
+		var stamp string
+		if err := rows.Scan(&id, &stamp); err != nil {
+			t.Fatal(err)
+		}
+		ids, stamps = append(ids, id), append(stamps, stamp)
+	}
+	if len(ids) != 2 || ids[0] >= ids[1] {
+		t.Fatalf("ordered by timestamp: ids %v, want ascending", ids)
+	}
+	if stamps[0] == stamps[1] {
+		t.Errorf("both webhooks stored at %s", stamps[0])
+	}
+	for i, stamp := range stamps {
+		got, ok := parseStoredTime(stamp)
+		if want := []time.Time{first, second}[i]; !ok || !got.Equal(want) {
+			t.Errorf("timestamp %q reads back as %v, want %v", stamp, got, want)
+		}
+	}
+}
+
+func TestSetupInputsFitTerminalWidth(t *testing.T) {
+	for _, tc := range []struct {
+		width                    int
+		port, subdomain, timeout int
+	}{
+		{120, portInputWidth, subdomainInputWidth, timeoutInputWidth},
+		{24, 20, 20, 10},
+		{12, 8, 8, 8},
+		{4, minSetupInputWidth, minSetupInputWidth, minSetupInputWidth},
+	} {
+		next, _ := initialModel().Update(tea.WindowSizeMsg{Width: tc.width, Height: 40})
+		m := next.(Model)
+		if m.portInput.Width != tc.port || m.subdomainInput.Width != tc.subdomain || m.timeoutInput.Width != tc.timeout {
+			t.Errorf("width %d: inputs %d/%d/%d, want %d/%d/%d", tc.width,
+				m.portInput.Width, m.subdomainInput.Width, m.timeoutInput.Width, tc.port, tc.subdomain, tc.timeout)
+		}
+	}
+}
+
+func TestRowsFitTerminalHeight(t *testing.T) {
+	var webhooks []WebhookPayload
+	for id := 40; id > 0; id-- {
+		webhooks = append(webhooks, WebhookPayload{ID: id, Timestamp: time.Now(), Method: "POST", Path: "/hooks", Body: "{}"})
+	}
+	for _, mode := range []ViewMode{ViewModeList, ViewModeTable} {
+		for _, height := range []int{30, 45, 60, 100} {
+			m := initialModel()
+			m.state = StateRunning
+			m.viewMode = mode
+			m.width, m.height = 120, height
+			m.webhooks = webhooks
+			m.selectedIdx = 25
+
+			view := m.View()
+			if lines := renderedLines(view, m.width); lines > height {
+				t.Errorf("mode %v, height %d: view takes %d lines", mode, height, lines)
+			}
+			// "#15 ..." in the list, "15   ..." in the table
+			selected := regexp.MustCompile(fmt.Sprintf(`(?m)^\W*#?%d\s`, webhooks[m.selectedIdx].ID))
+			if !selected.MatchString(view) {
+				t.Errorf("mode %v, height %d: selected webhook not shown", mode, height)
+			}
+		}
+	}
+}
+
+func TestFilterWildcardsAreLiteral(t *testing.T) {
+	openTestDB(t)
+	for _, path := range []string{"/a_b", "/axb", "/100%", "/1000", `/c\d`, "/cd"} {
+		if _, err := saveWebhookToDB(WebhookPayload{Timestamp: time.Now(), Method: "POST", Path: path, Body: path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, query := range []string{"a_b", "100%", `c\d`} {
+		for _, filter := range []webhookFilter{{Path: query}, {Body: query}} {
+			got, err := queryWebhooks(filter, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || !filter.matches(got[0]) {
+				t.Errorf("filter %s: got %d webhooks, want the 1 that matches() accepts", filter, len(got))
+			}
+		}
+	}
+
+	if msg := deleteFilteredWebhooks(webhookFilter{Path: "a_b"})(); msg != (webhooksDeletedMsg{count: 1}) {
+		t.Errorf("delete filtered: %#v, want 1 row deleted", msg)
+	}
+	if total, _ := countWebhooks(webhookFilter{}); total != 5 {
+		t.Errorf("%d webhooks left, want 5", total)
+	}
+}
+
+func TestFilterQueryRoundTrips(t *testing.T) {
+	f := webhookFilter{
+		Path:          "/events",
+		Method:        "POST",
+		Since:         10 * time.Minute,
+		Body:          "invoice",
+		HideProcessed: true,
+		MetaKey:       "env",
+		MetaValue:     "prod",
+		RunID:         "run-1",
+		JSONPath:      "data.type",
+		JSONValue:     "paid",
+		Regex:         `^/v\d+/`,
+	}
+	got, err := parseFilter(f.query())
+	if err != nil {
+		t.Fatalf("parseFilter(%q): %v", f.query(), err)
+	}
+	if got != f {
+		t.Errorf("parseFilter(%q) = %+v, want %+v", f.query(), got, f)
+	}
+}
+
+func TestInfoViewMasksSigningSecrets(t *testing.T) {
+	old := config.SigningSecrets
+	config.SigningSecrets = map[string]string{"github": "gh-secret-123", "stripe": "whsec_abc"}
+	defer func() { config.SigningSecrets = old }()
+
+	m := initialModel()
+	m.info = &dbInfo{}
+	view := m.viewInfo()
+	for provider, secret := range config.SigningSecrets {
+		if strings.Contains(view, secret) {
+			t.Errorf("info view shows the %s signing secret", provider)
+		}
+		if !strings.Contains(view, provider) {
+			t.Errorf("info view does not list the %s provider", provider)
+		}
+	}
+}
+
+func TestNgrokTunnelURL(t *testing.T) {
+	log := `{"lvl":"info","msg":"starting web service","obj":"web","addr":"127.0.0.1:4041"}
+{"addr":"http://localhost:3000","lvl":"info","msg":"started tunnel","name":"other","url":"https://other.ngrok-free.app"}
+{"addr":"http://localhost:8098","lvl":"info","msg":"started tunnel","name":"command_line","url":"https://ours.ngrok-free.app"}`
+	if got := ngrokTunnelURL(log, "8098"); got != "https://ours.ngrok-free.app" {
+		t.Errorf("ngrokTunnelURL = %q, want our tunnel", got)
+	}
+	if got := ngrokTunnelURL(log, "9000"); got != "" {
+		t.Errorf("ngrokTunnelURL for an unused port = %q, want none", got)
+	}
+
+	if got := ngrokLastError(`{"lvl":"eror","msg":"session closing","err":"authentication failed"}` + "\n"); got != "authentication failed" {
+		t.Errorf("ngrokLastError = %q", got)
+	}
+	if got := ngrokLastError("ERROR:  bind: address already in use\n"); got != "ERROR:  bind: address already in use" {
+		t.Errorf("ngrokLastError = %q", got)
+	}
+}
+
+func TestSaveSetupDefaultsDropsStaleTimeout(t *testing.T) {
+	old := configPath
+	configPath = filepath.Join(t.TempDir(), "config.json")
+	defer func() { configPath = old }()
+	if err := os.WriteFile(configPath, []byte(`{"default_timeout_minutes": 5, "view_mode": "table"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg := saveConfigCmd("setup defaults", setupDefaults("8098", "", 90*time.Second, "ngrok"))(); msg != (configSavedMsg{what: "setup defaults"}) {
+		t.Fatalf("saveConfigCmd: %#v", msg)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]interface{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := saved["default_timeout_minutes"]; ok {
+		t.Errorf("default_timeout_minutes kept after saving a 90s timeout: %s", data)
+	}
+	if saved["view_mode"] != "table" || saved["default_port"] != "8098" || saved["default_provider"] != "ngrok" {
+		t.Errorf("unexpected config: %s", data)
+	}
+	if _, ok := saved["default_subdomain"]; ok {
+		t.Errorf("empty subdomain saved: %s", data)
+	}
+}
+
+func TestKeyBindingsReplayTheirKey(t *testing.T) {
+	for _, k := range keyBindings {
+		if got := k.keyMsg().String(); got != k.key {
+			t.Errorf("binding %q replays %q", k.key, got)
+		}
+	}
+}
+
+func TestPaletteRunsCtrlKeys(t *testing.T) {
+	m := initialModel()
+	m.state = StateRunning
+	m.paletteMode = true
+	m.paletteInput.SetValue("notifications")
+	next, _ := m.update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !next.(Model).notify {
+		t.Error("running ctrl+n from the palette did not turn on notifications")
+	}
+}
+
+func TestAlertBellGoesOutWithTheFrame(t *testing.T) {
+	m := initialModel()
+	m.state = StateRunning
+	next, _ := m.update(alertMsg{rule: AlertRule{Path: "/hooks"}, id: 1, title: "POST /hooks"})
+	m = next.(Model)
+	if !strings.HasPrefix(m.View(), "\a") {
+		t.Fatal("frame after an alert does not start with BEL")
+	}
+	next, _ = m.update(bellEndMsg{})
+	if strings.Contains(next.(Model).View(), "\a") {
+		t.Error("BEL still in the frame after bellEndMsg")
+	}
+}
+
+func TestJSONFilterMatchesStoredLikeLive(t *testing.T) {
+	openTestDB(t)
+	body := `{"amount": 1.50, "ok": true, "0": {"x": "key"}, "items": [{"id": "a"}], "gone": null}`
+	bodyJSON, err := decodeJSON([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := WebhookPayload{Timestamp: time.Now(), Method: "POST", Path: "/hooks", Body: body, BodyJSON: bodyJSON}
+	if _, err := saveWebhookToDB(live); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{
+		"json:amount=1.50", "json:amount=1.5", "json:ok=true", "json:0.x=key", "json:items[0].id=a",
+		"json:items.0.id", "json:gone", "json:missing", "json:items.1",
+	} {
+		filter, err := parseFilter(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored, err := countWebhooks(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filter.matches(live); (stored == 1) != want {
+			t.Errorf("%s: %d stored webhooks match, live match is %v", query, stored, want)
+		}
+	}
+}
+
+func TestFieldFlagIsValidated(t *testing.T) {
+	for _, field := range []string{"=x", "$", "data..id"} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		buildFilter := addFilterFlags(fs)
+		if err := fs.Parse([]string{"--field", field}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := buildFilter(); err == nil {
+			t.Errorf("--field %q accepted", field)
+		}
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	body, err := decodeJSON([]byte(`{"data": {"items": [{"id": "a"}, {"id": "b"}], "0": "key"}, "n": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		path, want, err string
+	}{
+		{path: ".data.items[0].id", want: `"a"`},
+		{path: "$.data.items.1.id", want: `"b"`},
+		{path: "data.items[-1].id", want: `"b"`},
+		{path: ".data.0", want: `"key"`},
+		{path: ".", want: `{"data":{"0":"key","items":[{"id":"a"},{"id":"b"}]},"n":1}`},
+		{path: ".data.missing", err: `.data has no key "missing"`},
+		{path: ".data.items[2]", err: "index 2 out of range, .data.items has 2 elements"},
+		{path: ".data.items.id", err: `.data.items is an array, "id" is not an index`},
+		{path: ".n.x", err: ".n is a number, not an object or array"},
+		{path: ".data..items", err: "empty key after .data"},
+		{path: ".data.items[0", err: `unbalanced brackets in ".data.items[0"`},
+	} {
+		got, err := lookupJSONPath(body, tc.path)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%s: error %v, want %q", tc.path, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.path, err)
+			continue
+		}
+		if out, _ := json.Marshal(got); string(out) != tc.want {
+			t.Errorf("%s = %s, want %s", tc.path, out, tc.want)
+		}
+	}
+}
+
+func TestNotifyOffDropsHeldBurst(t *testing.T) {
+	m := initialModel()
+	m.state = StateRunning
+	m.notify = true
+	m.notifyHeld = true
+	m.notifySuppressed = 3
+	next, _ := m.update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	m = next.(Model)
+	if m.notify || m.notifyHeld || m.notifySuppressed != 0 {
+		t.Fatalf("after ctrl+n: notify %v, held %v, suppressed %d", m.notify, m.notifyHeld, m.notifySuppressed)
+	}
+
+	// A window that ends after notifications were turned off sends nothing
+	m.notifySuppressed = 2
+	if _, cmd := m.update(notifyWindowMsg{}); cmd != nil {
+		t.Error("notification window sent a summary with notifications off")
+	}
+}
+
+func TestQueryParamsKeepUndecodableValues(t *testing.T) {
+	wh := WebhookPayload{Method: "POST", Path: "/hooks", RawURI: "/hooks?a=1&a=x%20y&bad=%zz&%zz=v&flag"}
+	want := []queryParam{{"a", "1"}, {"a", "x y"}, {"bad", "%zz"}, {"%zz", "v"}, {"flag", ""}}
+	got := wh.queryParams()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("queryParams = %v, want %v", got, want)
+	}
+}
+
+func TestExportUnknownFormatKeepsOutputFile(t *testing.T) {
+	openTestDB(t)
+	output := filepath.Join(t.TempDir(), "webhooks.ndjson")
+	if err := os.WriteFile(output, []byte("keep\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runExport([]string{"--format", "xml", "--output", output}); err == nil {
+		t.Fatal("export with an unknown format succeeded")
+	}
+	if data, _ := os.ReadFile(output); string(data) != "keep\n" {
+		t.Errorf("output file is now %q", data)
+	}
+}
+
+func TestIdleInListIgnoresOpenInputs(t *testing.T) {
+	for name, set := range map[string]func(*Model){
+		"palette": func(m *Model) { m.paletteMode = true },
+		"filter":  func(m *Model) { m.filterMode = true },
+		"path":    func(m *Model) { m.pathMode = true },
+		"jq":      func(m *Model) { m.jqMode = true },
+		"search":  func(m *Model) { m.searchMode = true },
+		"replay":  func(m *Model) { m.replayMode = true },
+	} {
+		m := initialModel()
+		m.state = StateRunning
+		if !m.idleInList() {
+			t.Fatal("fresh list is not idle")
+		}
+		set(&m)
+		if m.idleInList() {
+			t.Errorf("list is idle with the %s input open", name)
+		}
+	}
+}
+
+func TestHARExportMatchesEncodedLog(t *testing.T) {
+	openTestDB(t)
+	for n := 0; n < 3; n++ {
+		har := newHARLog()
+		if err := forEachWebhook(webhookFilter{}, func(wh WebhookPayload) error {
+			har.Log.Entries = append(har.Log.Entries, webhookToHAREntry(wh, "http://localhost"))
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		var want bytes.Buffer
+		enc := json.NewEncoder(&want)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(har); err != nil {
+			t.Fatal(err)
+		}
+
+		var got bytes.Buffer
+		count, err := exportWebhooks(&got, "har", webhookFilter{}, exportOptions{baseURL: "http://localhost"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != n || got.String() != want.String() {
+			t.Errorf("%d webhooks: streamed HAR (count %d)\n%s\nwant\n%s", n, count, got.String(), want.String())
+		}
+
+		if _, err := saveWebhookToDB(WebhookPayload{Timestamp: time.Now(), Method: "POST", Path: "/hooks", Body: `{"n": 1}`}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExportBundle(t *testing.T) {
+	openTestDB(t)
+	for _, path := range []string{"/a", "/b", "/a/c"} {
+		if _, err := saveWebhookToDB(WebhookPayload{Timestamp: time.Now(), Method: "POST", Path: path, Body: "{}"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	msg := exportBundle(webhookFilter{Path: "/a"}, sessionMeta{ExportedAt: time.Now()}, "http://localhost")()
+	done, ok := msg.(exportDoneMsg)
+	if !ok || done.count != 2 {
+		t.Fatalf("exportBundle: %#v, want 2 webhooks exported", msg)
+	}
+
+	zr, err := zip.OpenReader(done.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if !strings.HasSuffix(f.Name, ".json") && !strings.HasSuffix(f.Name, ".har") {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v interface{}
+		if err := json.NewDecoder(r).Decode(&v); err != nil {
+			t.Errorf("%s: %v", f.Name, err)
+		}
+		r.Close()
+	}
+	if got := strings.Join(names, " "); got != "webhooks.json webhooks.har session.json README.md" {
+		t.Errorf("bundle holds %s", got)
+	}
+}
+
+func TestDetailShowsTunnelURLFromHistory(t *testing.T) {
+	openTestDB(t)
+	if msg := recordSession("https://old.example", "203.0.113.1", "8098")(); msg != nil {
+		t.Fatalf("recordSession: %v", msg)
+	}
+	m := initialModel()
+	m.state = StateRunning
+	m.webhooks = []WebhookPayload{{ID: 7, Timestamp: time.Now().Add(time.Second), Method: "POST", Path: "/hooks", Body: "{}"}}
+
+	next, cmd := m.update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	if m.state != StateDetail || m.detailTunnelURL != "" {
+		t.Fatalf("after enter: state %v, tunnel URL %q", m.state, m.detailTunnelURL)
+	}
+	msg := loadTunnelURLAt(7, m.webhooks[0].Timestamp)()
+	if msg != (detailTunnelURLMsg{id: 7, url: "https://old.example"}) {
+		t.Fatalf("loadTunnelURLAt: %#v", msg)
+	}
+	if cmd == nil {
+		t.Fatal("opening the detail view returned no command")
+	}
+	next, _ = m.update(msg)
+	if got := next.(Model).detailTunnelURL; got != "https://old.example" {
+		t.Errorf("detail tunnel URL %q", got)
+	}
+}
+
+func TestViewModeIsSavedInACommand(t *testing.T) {
+	oldPath := configPath
+	configPath = filepath.Join(t.TempDir(), "config.json")
+	t.Cleanup(func() { configPath = oldPath })
+
+	m := initialModel()
+	m.state = StateRunning
+	m.viewMode = ViewModeTable
+	next, cmd := m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if next.(Model).viewMode != ViewModeList {
+		t.Fatal("t did not switch to the list view")
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Fatal("config written inside update")
+	}
+	if cmd == nil {
+		t.Fatal("no command to save the view mode")
+	}
+	if msg := saveConfigCmd("view mode", map[string]interface{}{"view_mode": "list"})(); msg != (configSavedMsg{what: "view mode"}) {
+		t.Fatalf("saveConfigCmd: %#v", msg)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil || !strings.Contains(string(data), `"view_mode": "list"`) {
+		t.Errorf("config file %q, %v", data, err)
+	}
+
+	// A failed save is reported in the status line
+	next, _ = next.(Model).update(configSavedMsg{what: "view mode", err: fmt.Errorf("read-only")})
+	if m := next.(Model); !m.statusErr || m.statusMsg != "Could not save view mode: read-only" {
+		t.Errorf("status %q (error %v)", m.statusMsg, m.statusErr)
+	}
+}
+
+func TestExpiredTunnelStopsServersInACommand(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: http.NotFoundHandler()}
+	go srv.Serve(ln)
+	url := "http://" + ln.Addr().String()
+
+	m := initialModel()
+	m.state = StateRunning
+	m.servers = []*http.Server{srv}
+	m.serverRunning = true
+	m.tunnelRunning = true
+	m.tunnelTimeout = time.Minute
+	m.tunnelStartTime = time.Now().Add(-time.Hour)
+	next, cmd := m.update(tunnelExpiredMsg{})
+	m = next.(Model)
+	if cmd == nil {
+		t.Fatal("no command to stop the servers")
+	}
+	if m.serverRunning || m.serverStopped {
+		t.Errorf("running %v, stopped %v before the servers stopped", m.serverRunning, m.serverStopped)
+	}
+	if resp, err := http.Get(url); err != nil {
+		t.Fatalf("server shut down inside update: %v", err)
+	} else {
+		resp.Body.Close()
+	}
+
+	msg := stopServers(m.servers)()
+	if _, err := http.Get(url); err == nil {
+		t.Error("server still answering after stopServers")
+	}
+	next, _ = m.update(msg)
+	if !next.(Model).serverStopped {
+		t.Error("serversStoppedMsg did not mark the servers stopped")
+	}
+}
+
+func TestStartupPurgeOnlyReportsRemovals(t *testing.T) {
+	openTestDB(t)
+	for _, ts := range []time.Time{time.Now().AddDate(0, 0, -10), time.Now()} {
+		if _, err := saveWebhookToDB(WebhookPayload{Timestamp: ts, Method: "POST", Path: "/a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := initialModel()
+	m.state = StateRunning
+	msg := purgeOnStartup(7)()
+	if purged, ok := msg.(startupPurgedMsg); !ok || purged.count != 1 {
+		t.Fatalf("first purge: %#v, want 1 row removed", msg)
+	}
+	next, cmd := m.update(msg)
+	if status := next.(Model).statusMsg; !strings.HasPrefix(status, "Deleted 1 webhooks") || cmd == nil {
+		t.Errorf("first purge: status %q, reload %v", status, cmd != nil)
+	}
+
+	// Nothing left to purge: no status and no extra reload
+	next, cmd = m.update(purgeOnStartup(7)())
+	if status := next.(Model).statusMsg; status != "" || cmd != nil {
+		t.Errorf("empty purge: status %q, command %v", status, cmd != nil)
+	}
+}
+
+func TestCutAtRune(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"abc", 5, "abc"},
+		{"abc", 2, "ab"},
+		{"aé", 2, "a"},  // é is two bytes
+		{"a€b", 3, "a"}, // € is three bytes
+		{"a€b", 4, "a€"},
+	} {
+		if got := cutAtRune(tc.s, tc.max); got != tc.want {
+			t.Errorf("cutAtRune(%q, %d) = %q, want %q", tc.s, tc.max, got, tc.want)
+		}
+	}
+}